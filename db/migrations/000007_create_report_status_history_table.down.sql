@@ -0,0 +1 @@
+DROP TABLE report_status_history;
\ No newline at end of file
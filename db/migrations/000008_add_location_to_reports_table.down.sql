@@ -0,0 +1,9 @@
+ALTER TABLE reports ADD COLUMN lat NUMERIC, ADD COLUMN lng NUMERIC;
+
+UPDATE reports SET lat = ST_Y(location::geometry), lng = ST_X(location::geometry);
+
+ALTER TABLE reports ALTER COLUMN lat SET NOT NULL, ALTER COLUMN lng SET NOT NULL;
+
+DROP INDEX reports_location_idx;
+
+ALTER TABLE reports DROP COLUMN location;
@@ -0,0 +1,11 @@
+CREATE EXTENSION IF NOT EXISTS postgis;
+
+ALTER TABLE reports ADD COLUMN location geography(Point, 4326);
+
+UPDATE reports SET location = ST_SetSRID(ST_MakePoint(lng, lat), 4326)::geography;
+
+ALTER TABLE reports ALTER COLUMN location SET NOT NULL;
+
+ALTER TABLE reports DROP COLUMN lat, DROP COLUMN lng;
+
+CREATE INDEX reports_location_idx ON reports USING GIST (location);
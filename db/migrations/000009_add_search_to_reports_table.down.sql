@@ -0,0 +1,7 @@
+DROP INDEX reports_note_trgm_idx;
+
+DROP INDEX reports_address_trgm_idx;
+
+DROP INDEX reports_search_vector_idx;
+
+ALTER TABLE reports DROP COLUMN search_vector;
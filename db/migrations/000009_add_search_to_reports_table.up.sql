@@ -0,0 +1,12 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE reports ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('indonesian', address), 'A') ||
+    setweight(to_tsvector('indonesian', note), 'B')
+) STORED;
+
+CREATE INDEX reports_search_vector_idx ON reports USING GIN (search_vector);
+
+CREATE INDEX reports_address_trgm_idx ON reports USING GIN (address gin_trgm_ops);
+
+CREATE INDEX reports_note_trgm_idx ON reports USING GIN (note gin_trgm_ops);
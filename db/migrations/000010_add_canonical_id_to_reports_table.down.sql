@@ -0,0 +1,3 @@
+DROP INDEX reports_canonical_id_idx;
+
+ALTER TABLE reports DROP COLUMN canonical_id;
@@ -0,0 +1,3 @@
+ALTER TABLE reports ADD COLUMN canonical_id INTEGER REFERENCES reports (id) ON DELETE SET NULL;
+
+CREATE INDEX reports_canonical_id_idx ON reports (canonical_id);
@@ -0,0 +1,6 @@
+CREATE TABLE report_confirmations (
+    report_id INTEGER NOT NULL REFERENCES reports (id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (report_id, user_id)
+);
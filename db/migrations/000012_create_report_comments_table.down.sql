@@ -0,0 +1 @@
+DROP TABLE report_comments;
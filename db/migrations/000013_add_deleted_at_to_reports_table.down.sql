@@ -0,0 +1,3 @@
+DROP INDEX reports_deleted_at_idx;
+
+ALTER TABLE reports DROP COLUMN deleted_at;
@@ -0,0 +1,3 @@
+ALTER TABLE reports ADD COLUMN deleted_at TIMESTAMP;
+
+CREATE INDEX reports_deleted_at_idx ON reports (deleted_at);
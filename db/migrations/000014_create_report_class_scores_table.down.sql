@@ -0,0 +1,5 @@
+DROP TABLE report_class_scores;
+
+DROP INDEX reports_score_idx;
+
+ALTER TABLE reports DROP COLUMN score;
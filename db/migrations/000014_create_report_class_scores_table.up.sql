@@ -0,0 +1,10 @@
+ALTER TABLE reports ADD COLUMN score REAL;
+
+CREATE INDEX reports_score_idx ON reports (score);
+
+CREATE TABLE report_class_scores (
+    report_id INTEGER NOT NULL REFERENCES reports (id) ON DELETE CASCADE,
+    class class NOT NULL,
+    score REAL NOT NULL,
+    PRIMARY KEY (report_id, class)
+);
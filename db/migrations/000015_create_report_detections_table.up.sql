@@ -0,0 +1,13 @@
+CREATE TABLE report_detections (
+    id SERIAL PRIMARY KEY,
+    report_id INTEGER NOT NULL REFERENCES reports (id) ON DELETE CASCADE,
+    class class NOT NULL,
+    score REAL NOT NULL,
+    x_min REAL NOT NULL,
+    y_min REAL NOT NULL,
+    x_max REAL NOT NULL,
+    y_max REAL NOT NULL,
+    CHECK (x_min <= x_max AND y_min <= y_max)
+);
+
+CREATE INDEX report_detections_report_id_idx ON report_detections (report_id);
@@ -0,0 +1,13 @@
+ALTER TABLE report_detections DROP COLUMN source;
+
+DROP TYPE detection_source;
+
+DROP INDEX reports_relabeled_at_idx;
+
+ALTER TABLE reports DROP COLUMN detections_relabeled;
+
+ALTER TABLE reports DROP COLUMN relabeled_at;
+
+ALTER TABLE reports DROP COLUMN relabeled_by;
+
+ALTER TABLE reports DROP COLUMN predicted_classes;
@@ -0,0 +1,17 @@
+ALTER TABLE reports ADD COLUMN predicted_classes class[];
+
+UPDATE reports SET predicted_classes = classes;
+
+ALTER TABLE reports ALTER COLUMN predicted_classes SET NOT NULL;
+
+ALTER TABLE reports ADD COLUMN relabeled_by INTEGER REFERENCES users (id) ON DELETE SET NULL;
+
+ALTER TABLE reports ADD COLUMN relabeled_at TIMESTAMP;
+
+ALTER TABLE reports ADD COLUMN detections_relabeled BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX reports_relabeled_at_idx ON reports (relabeled_at);
+
+CREATE TYPE detection_source AS ENUM ('model', 'human');
+
+ALTER TABLE report_detections ADD COLUMN source detection_source NOT NULL DEFAULT 'model';
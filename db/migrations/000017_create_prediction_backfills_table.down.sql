@@ -0,0 +1,7 @@
+DROP TABLE report_predictions;
+
+DROP TABLE prediction_backfills;
+
+DROP TYPE backfill_status;
+
+ALTER TABLE reports DROP COLUMN model_version;
@@ -0,0 +1,35 @@
+ALTER TABLE reports ADD COLUMN model_version TEXT;
+
+CREATE TYPE backfill_status AS ENUM ('Running', 'Paused', 'Completed');
+
+CREATE TABLE prediction_backfills (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    status backfill_status NOT NULL DEFAULT 'Running',
+    filter JSONB NOT NULL,
+    rate REAL NOT NULL,
+    model_version TEXT,
+    last_report_id INTEGER NOT NULL DEFAULT 0,
+    processed INTEGER NOT NULL DEFAULT 0,
+    skipped INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX prediction_backfills_running_idx ON prediction_backfills ((TRUE)) WHERE status = 'Running';
+
+CREATE TABLE report_predictions (
+    id SERIAL PRIMARY KEY,
+    report_id INTEGER NOT NULL REFERENCES reports (id) ON DELETE CASCADE,
+    backfill_id INTEGER REFERENCES prediction_backfills (id) ON DELETE SET NULL,
+    model_version TEXT NOT NULL,
+    classes class[] NOT NULL,
+    score REAL,
+    class_scores JSONB,
+    detections JSONB,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (report_id, model_version)
+);
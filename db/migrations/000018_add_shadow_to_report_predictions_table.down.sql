@@ -0,0 +1,7 @@
+DROP INDEX report_predictions_shadow_idx;
+
+DELETE FROM report_predictions WHERE shadow;
+ALTER TABLE report_predictions DROP CONSTRAINT report_predictions_report_id_model_version_shadow_key;
+ALTER TABLE report_predictions ADD CONSTRAINT report_predictions_report_id_model_version_key UNIQUE (report_id, model_version);
+
+ALTER TABLE report_predictions DROP COLUMN shadow;
@@ -0,0 +1,6 @@
+ALTER TABLE report_predictions ADD COLUMN shadow BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE report_predictions DROP CONSTRAINT report_predictions_report_id_model_version_key;
+ALTER TABLE report_predictions ADD CONSTRAINT report_predictions_report_id_model_version_shadow_key UNIQUE (report_id, model_version, shadow);
+
+CREATE INDEX report_predictions_shadow_idx ON report_predictions (model_version) WHERE shadow;
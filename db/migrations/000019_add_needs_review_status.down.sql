@@ -0,0 +1,21 @@
+ALTER TABLE reports DROP COLUMN review_reason;
+
+UPDATE reports SET status = 'Reported' WHERE status = 'Needs Review';
+
+DELETE FROM report_status_history WHERE old_status = 'Needs Review' OR new_status = 'Needs Review';
+
+ALTER TYPE status RENAME TO status_old;
+
+CREATE TYPE status AS ENUM ('Reported', 'Under Repair', 'Completed', 'Rejected');
+
+ALTER TABLE reports ALTER COLUMN status DROP DEFAULT;
+
+ALTER TABLE reports ALTER COLUMN status TYPE status USING status::text::status;
+
+ALTER TABLE reports ALTER COLUMN status SET DEFAULT 'Reported';
+
+ALTER TABLE report_status_history ALTER COLUMN old_status TYPE status USING old_status::text::status;
+
+ALTER TABLE report_status_history ALTER COLUMN new_status TYPE status USING new_status::text::status;
+
+DROP TYPE status_old;
@@ -0,0 +1,3 @@
+ALTER TYPE status ADD VALUE 'Needs Review' BEFORE 'Reported';
+
+ALTER TABLE reports ADD COLUMN review_reason TEXT;
@@ -0,0 +1 @@
+DROP INDEX reports_location_3857_idx;
@@ -0,0 +1 @@
+CREATE INDEX reports_location_3857_idx ON reports USING GIST (ST_Transform(location::geometry, 3857));
@@ -17,4 +17,6 @@ require (
 	github.com/ory/dockertest/v3 v3.6.5
 	github.com/rs/zerolog v1.22.0
 	golang.org/x/crypto v0.0.0-20210322153248-0c34fe9e7dc2
+	google.golang.org/grpc v1.38.0
+	google.golang.org/protobuf v1.26.0
 )
@@ -0,0 +1,170 @@
+// Package annotate draws the detections of the damage model on top of the
+// photo they were found in.
+package annotate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+
+	// Register the formats report photos are uploaded in.
+	_ "image/png"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+)
+
+const jpegQuality = 90
+
+// MaxPixels caps the size of photos Render decodes, since a small compressed
+// file can expand to an image too large to hold in memory.
+const MaxPixels = 50_000_000
+
+// ErrTooLarge is returned by Render for photos of more than MaxPixels.
+var ErrTooLarge = errors.New("image too large")
+
+// ContentType is the content type of rendered images.
+const ContentType = "image/jpeg"
+
+// classColors gives every damage class its own colour so reviewers can tell
+// them apart without reading the labels.
+var classColors = map[string]color.RGBA{
+	"D00": {R: 0xe6, G: 0x19, B: 0x4b, A: 0xff},
+	"D01": {R: 0xf5, G: 0x82, B: 0x31, A: 0xff},
+	"D10": {R: 0xff, G: 0xe1, B: 0x19, A: 0xff},
+	"D11": {R: 0xbf, G: 0xef, B: 0x45, A: 0xff},
+	"D20": {R: 0x3c, G: 0xb4, B: 0x4b, A: 0xff},
+	"D40": {R: 0x42, G: 0xd4, B: 0xf4, A: 0xff},
+	"D43": {R: 0x43, G: 0x63, B: 0xd8, A: 0xff},
+	"D44": {R: 0x91, G: 0x1e, B: 0xb4, A: 0xff},
+	"D50": {R: 0xf0, G: 0x32, B: 0xe6, A: 0xff},
+}
+
+var defaultColor = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+
+// ClassColor returns the colour detections of class are drawn in.
+func ClassColor(class string) color.RGBA {
+	if c, ok := classColors[class]; ok {
+		return c
+	}
+
+	return defaultColor
+}
+
+// Render decodes the photo read from r, draws the detections on it and writes
+// the result to w as a JPEG. Its size is checked from the header before the
+// photo is decoded.
+func Render(w io.Writer, r io.Reader, detections []*entity.ReportDetection) error {
+	header := new(bytes.Buffer)
+	config, _, err := image.DecodeConfig(io.TeeReader(r, header))
+	if err != nil {
+		return fmt.Errorf("decode image: %w", err)
+	}
+	if int64(config.Width)*int64(config.Height) > MaxPixels {
+		return fmt.Errorf("%w: %dx%d", ErrTooLarge, config.Width, config.Height)
+	}
+
+	src, _, err := image.Decode(io.MultiReader(header, r))
+	if err != nil {
+		return fmt.Errorf("decode image: %w", err)
+	}
+
+	return jpeg.Encode(w, Draw(src, detections), &jpeg.Options{Quality: jpegQuality})
+}
+
+// Draw returns a copy of src with a box and a label for every detection.
+func Draw(src image.Image, detections []*entity.ReportDetection) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Src)
+
+	// Keep boxes and labels readable regardless of the photo resolution.
+	short := bounds.Dx()
+	if bounds.Dy() < short {
+		short = bounds.Dy()
+	}
+	stroke := max(2, short/200)
+	scale := max(2, short/250)
+
+	for _, detection := range detections {
+		if detection.Box == nil {
+			continue
+		}
+		c := ClassColor(detection.Class)
+		box := image.Rect(
+			int(detection.Box.XMin),
+			int(detection.Box.YMin),
+			int(detection.Box.XMax),
+			int(detection.Box.YMax),
+		).Intersect(dst.Bounds())
+		if box.Empty() {
+			continue
+		}
+
+		drawOutline(dst, box, c, stroke)
+		drawLabel(dst, box, label(detection), c, scale)
+	}
+
+	return dst
+}
+
+func label(detection *entity.ReportDetection) string {
+	score := detection.Score
+	// The predictor may report confidence as a fraction or a percentage.
+	if score <= 1 {
+		score *= 100
+	}
+
+	return fmt.Sprintf("%s %.0f%%", detection.Class, score)
+}
+
+func drawOutline(dst draw.Image, box image.Rectangle, c color.Color, stroke int) {
+	src := image.NewUniform(c)
+	for _, edge := range []image.Rectangle{
+		image.Rect(box.Min.X, box.Min.Y, box.Max.X, box.Min.Y+stroke),
+		image.Rect(box.Min.X, box.Max.Y-stroke, box.Max.X, box.Max.Y),
+		image.Rect(box.Min.X, box.Min.Y, box.Min.X+stroke, box.Max.Y),
+		image.Rect(box.Max.X-stroke, box.Min.Y, box.Max.X, box.Max.Y),
+	} {
+		draw.Draw(dst, edge.Intersect(box), src, image.Point{}, draw.Src)
+	}
+}
+
+// drawLabel draws text on a filled tag just above the box, or just inside it
+// when the box touches the top of the photo.
+func drawLabel(dst draw.Image, box image.Rectangle, text string, c color.RGBA, scale int) {
+	size := textSize(text, scale)
+	padding := scale
+	tag := image.Rect(0, 0, size.X+2*padding, size.Y+2*padding)
+
+	origin := image.Pt(box.Min.X, box.Min.Y-tag.Dy())
+	if origin.Y < dst.Bounds().Min.Y {
+		origin.Y = box.Min.Y
+	}
+	tag = tag.Add(origin)
+
+	draw.Draw(dst, tag.Intersect(dst.Bounds()), image.NewUniform(c), image.Point{}, draw.Src)
+	drawText(dst, tag.Min.Add(image.Pt(padding, padding)), text, textColor(c), scale)
+}
+
+// textColor picks black or white, whichever reads better on background.
+func textColor(background color.RGBA) color.Color {
+	luma := 299*int(background.R) + 587*int(background.G) + 114*int(background.B)
+	if luma > 128*1000 {
+		return color.Black
+	}
+
+	return color.White
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
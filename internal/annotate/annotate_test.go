@@ -0,0 +1,160 @@
+package annotate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+)
+
+func newPhoto(w, h int) *image.RGBA {
+	photo := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			photo.Set(x, y, color.RGBA{R: 0x80, G: 0x80, B: 0x80, A: 0xff})
+		}
+	}
+
+	return photo
+}
+
+func TestDraw(t *testing.T) {
+	photo := newPhoto(400, 300)
+	detections := []*entity.ReportDetection{
+		{
+			Class: "D40",
+			Score: 0.9,
+			Box:   &entity.Box{XMin: 100, YMin: 100, XMax: 200, YMax: 200},
+		},
+		{
+			Class: "D00",
+			Score: 75,
+			Box:   &entity.Box{XMin: 0, YMin: 0, XMax: 50, YMax: 50},
+		},
+		{
+			Class: "D10",
+			Score: 60,
+			Box:   &entity.Box{XMin: 500, YMin: 500, XMax: 600, YMax: 600},
+		},
+	}
+
+	annotated := Draw(photo, detections)
+
+	t.Run("draw box outline in class colour", func(t *testing.T) {
+		if got := annotated.RGBAAt(150, 199); got != ClassColor("D40") {
+			t.Errorf("Expecting bottom edge to be %v but got %v instead", ClassColor("D40"), got)
+		}
+
+		if got := annotated.RGBAAt(101, 150); got != ClassColor("D40") {
+			t.Errorf("Expecting left edge to be %v but got %v instead", ClassColor("D40"), got)
+		}
+	})
+
+	t.Run("leave inside of box untouched", func(t *testing.T) {
+		if got := annotated.RGBAAt(150, 150); got != photo.RGBAAt(150, 150) {
+			t.Errorf("Expecting inside of box to be %v but got %v instead", photo.RGBAAt(150, 150), got)
+		}
+	})
+
+	t.Run("draw label above box", func(t *testing.T) {
+		if got := annotated.RGBAAt(100, 95); got != ClassColor("D40") {
+			t.Errorf("Expecting label tag to be %v but got %v instead", ClassColor("D40"), got)
+		}
+	})
+
+	t.Run("draw label inside box at the top of the photo", func(t *testing.T) {
+		if got := annotated.RGBAAt(1, 5); got != ClassColor("D00") {
+			t.Errorf("Expecting label tag to be %v but got %v instead", ClassColor("D00"), got)
+		}
+	})
+
+	t.Run("keep original photo", func(t *testing.T) {
+		if got := photo.RGBAAt(150, 199); got == ClassColor("D40") {
+			t.Error("Expecting the original photo to be left unchanged")
+		}
+	})
+}
+
+func TestLabel(t *testing.T) {
+	cases := []struct {
+		score float64
+		want  string
+	}{
+		{0.874, "D20 87%"},
+		{87.4, "D20 87%"},
+	}
+	for _, c := range cases {
+		got := label(&entity.ReportDetection{Class: "D20", Score: c.score})
+		if got != c.want {
+			t.Errorf("Expecting label to be %q but got %q instead", c.want, got)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	src := new(bytes.Buffer)
+	if err := png.Encode(src, newPhoto(64, 48)); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := new(bytes.Buffer)
+	if err := Render(dst, src, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := jpeg.Decode(dst)
+	if err != nil {
+		t.Fatalf("Expecting a JPEG but got error %v", err)
+	}
+
+	if img.Bounds().Dx() != 64 || img.Bounds().Dy() != 48 {
+		t.Errorf("Expecting size to be 64x48 but got %v instead", img.Bounds().Size())
+	}
+
+	if err := Render(new(bytes.Buffer), bytes.NewBufferString("not an image"), nil); err == nil {
+		t.Error("Expecting an error when decoding garbage")
+	}
+}
+
+func TestRenderTooLarge(t *testing.T) {
+	// Only the header of a PNG is needed to tell its size.
+	ihdr := make([]byte, 17)
+	copy(ihdr, "IHDR")
+	binary.BigEndian.PutUint32(ihdr[4:], 100000)
+	binary.BigEndian.PutUint32(ihdr[8:], 100000)
+	ihdr[12], ihdr[13] = 8, 2
+
+	src := bytes.NewBufferString("\x89PNG\r\n\x1a\n")
+	binary.Write(src, binary.BigEndian, uint32(len(ihdr)-4))
+	src.Write(ihdr)
+	binary.Write(src, binary.BigEndian, crc32.ChecksumIEEE(ihdr))
+
+	if err := Render(new(bytes.Buffer), src, nil); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Expecting ErrTooLarge but got %v instead", err)
+	}
+}
+
+func TestCache(t *testing.T) {
+	cache := NewCache(2)
+	cache.Add("a", []byte("a"))
+	cache.Add("b", []byte("b"))
+	cache.Get("a")
+	cache.Add("c", []byte("c"))
+
+	if _, ok := cache.Get("b"); ok {
+		t.Error("Expecting least recently used entry to be evicted")
+	}
+
+	for _, key := range []string{"a", "c"} {
+		if data, ok := cache.Get(key); !ok || string(data) != key {
+			t.Errorf("Expecting %q to be cached", key)
+		}
+	}
+}
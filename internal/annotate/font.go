@@ -0,0 +1,72 @@
+package annotate
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+const (
+	glyphWidth   = 5
+	glyphHeight  = 7
+	glyphSpacing = 1
+)
+
+// glyphs is a 5x7 bitmap font covering what detection labels need: class
+// codes and confidence percentages.
+var glyphs = map[rune][glyphHeight]string{
+	'0': {" ### ", "#   #", "#  ##", "# # #", "##  #", "#   #", " ### "},
+	'1': {"  #  ", " ##  ", "  #  ", "  #  ", "  #  ", "  #  ", " ### "},
+	'2': {" ### ", "#   #", "    #", "   # ", "  #  ", " #   ", "#####"},
+	'3': {"#####", "   # ", "  #  ", "   # ", "    #", "#   #", " ### "},
+	'4': {"   # ", "  ## ", " # # ", "#  # ", "#####", "   # ", "   # "},
+	'5': {"#####", "#    ", "#### ", "    #", "    #", "#   #", " ### "},
+	'6': {"  ## ", " #   ", "#    ", "#### ", "#   #", "#   #", " ### "},
+	'7': {"#####", "    #", "   # ", "  #  ", " #   ", " #   ", " #   "},
+	'8': {" ### ", "#   #", "#   #", " ### ", "#   #", "#   #", " ### "},
+	'9': {" ### ", "#   #", "#   #", " ####", "    #", "   # ", " ##  "},
+	'D': {"#### ", "#   #", "#   #", "#   #", "#   #", "#   #", "#### "},
+	'%': {"##   ", "##  #", "   # ", "  #  ", " #   ", "#  ##", "   ##"},
+	'.': {"     ", "     ", "     ", "     ", "     ", " ##  ", " ##  "},
+	' ': {"     ", "     ", "     ", "     ", "     ", "     ", "     "},
+}
+
+// textSize returns the size of text drawn at the given scale.
+func textSize(text string, scale int) image.Point {
+	n := len([]rune(text))
+	if n == 0 {
+		return image.Point{}
+	}
+
+	return image.Pt(
+		(n*(glyphWidth+glyphSpacing)-glyphSpacing)*scale,
+		glyphHeight*scale,
+	)
+}
+
+// drawText draws text with its top left corner at pt. Every font pixel
+// becomes a scale by scale square. Characters without a glyph are skipped.
+func drawText(dst draw.Image, pt image.Point, text string, c color.Color, scale int) {
+	src := image.NewUniform(c)
+	x := pt.X
+	for _, r := range text {
+		glyph, ok := glyphs[r]
+		if ok {
+			for row, line := range glyph {
+				for col, pixel := range line {
+					if pixel != '#' {
+						continue
+					}
+					rect := image.Rect(
+						x+col*scale,
+						pt.Y+row*scale,
+						x+(col+1)*scale,
+						pt.Y+(row+1)*scale,
+					)
+					draw.Draw(dst, rect, src, image.Point{}, draw.Src)
+				}
+			}
+		}
+		x += (glyphWidth + glyphSpacing) * scale
+	}
+}
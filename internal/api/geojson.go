@@ -0,0 +1,74 @@
+package api
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const GeoJSONContentType = "application/geo+json"
+
+type FeatureCollection struct {
+	Type     string     `json:"type"`
+	Features []*Feature `json:"features"`
+}
+
+type Feature struct {
+	Type       string                 `json:"type"`
+	ID         interface{}            `json:"id,omitempty"`
+	Geometry   *Point                 `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type Point struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+func NewFeatureCollection(features []*Feature) *FeatureCollection {
+	if features == nil {
+		features = []*Feature{}
+	}
+
+	return &FeatureCollection{
+		Type:     "FeatureCollection",
+		Features: features,
+	}
+}
+
+func NewPointFeature(id interface{}, lng, lat float64, properties map[string]interface{}) *Feature {
+	return &Feature{
+		Type: "Feature",
+		ID:   id,
+		Geometry: &Point{
+			Type:        "Point",
+			Coordinates: [2]float64{lng, lat},
+		},
+		Properties: properties,
+	}
+}
+
+func (fc *FeatureCollection) SendGeoJSON(w http.ResponseWriter, status int) error {
+	w.Header().Set("Content-Type", GeoJSONContentType)
+	w.WriteHeader(status)
+
+	return json.NewEncoder(w).Encode(fc)
+}
+
+// WantsGeoJSON reports whether the client asked for GeoJSON, either with a
+// format=geojson query parameter or an Accept header.
+func WantsGeoJSON(r *http.Request) bool {
+	if strings.EqualFold(r.URL.Query().Get("format"), "geojson") {
+		return true
+	}
+
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err == nil && mediaType == GeoJSONContentType {
+			return true
+		}
+	}
+
+	return false
+}
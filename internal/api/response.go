@@ -12,6 +12,7 @@ type Response struct {
 	Status  int         `json:"status"`
 	Message string      `json:"message"`
 	Data    interface{} `json:"data"`
+	Meta    interface{} `json:"meta,omitempty"`
 }
 
 type ErrorResponse struct {
@@ -28,6 +29,12 @@ func NewResponse(status int, message string, data interface{}) *Response {
 	}
 }
 
+func (r *Response) WithMeta(meta interface{}) *Response {
+	r.Meta = meta
+
+	return r
+}
+
 func (r *Response) SendJSON(w http.ResponseWriter) error {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(r.Status)
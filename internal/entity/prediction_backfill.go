@@ -0,0 +1,39 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	BackfillStatusRunning   = "Running"
+	BackfillStatusPaused    = "Paused"
+	BackfillStatusCompleted = "Completed"
+)
+
+// Outcomes of re-running prediction on a single report.
+const (
+	BackfillResultProcessed = "processed"
+	BackfillResultSkipped   = "skipped"
+	BackfillResultFailed    = "failed"
+)
+
+// PredictionBackfill re-runs prediction on the reports matching Filter, in
+// order of id. LastReportID is the last report handled so that an interrupted
+// backfill picks up where it stopped.
+type PredictionBackfill struct {
+	ID           int             `json:"id"`
+	UserID       *int            `json:"-"`
+	Status       string          `json:"status"`
+	Filter       json.RawMessage `json:"filter"`
+	Rate         float64         `json:"rate"`
+	ModelVersion *string         `json:"modelVersion,omitempty"`
+	LastReportID int             `json:"lastReportId"`
+	Processed    int             `json:"processed"`
+	Skipped      int             `json:"skipped"`
+	Failed       int             `json:"failed"`
+	LastError    *string         `json:"lastError,omitempty"`
+	CreatedAt    time.Time       `json:"createdAt"`
+	UpdatedAt    time.Time       `json:"updatedAt"`
+	FinishedAt   *time.Time      `json:"finishedAt,omitempty"`
+}
@@ -2,17 +2,43 @@ package entity
 
 import "time"
 
+// Reasons for a report to wait for an admin to review its prediction before it
+// is published.
+const (
+	ReviewReasonNoDamage      = "no_damage"
+	ReviewReasonLowConfidence = "low_confidence"
+)
+
+// ReportClasses lists the damage classes in the order the model knows them.
+var ReportClasses = []string{"D00", "D01", "D10", "D11", "D20", "D40", "D43", "D44", "D50"}
+
 type Report struct {
-	ID           int       `json:"id"`
-	UserID       int       `json:"-"`
-	ReporterName string    `json:"reporterName"`
-	Status       string    `json:"status"`
-	ImageURL     string    `json:"imageUrl"`
-	Classes      []string  `json:"classes"`
-	Note         string    `json:"note"`
-	Address      string    `json:"address"`
-	Location     *Location `json:"location"`
-	DateReported time.Time `json:"dateReported"`
+	ID                  int                    `json:"id"`
+	UserID              int                    `json:"-"`
+	ReporterName        string                 `json:"reporterName"`
+	Status              string                 `json:"status"`
+	ReviewReason        string                 `json:"reviewReason,omitempty"`
+	ImageURL            string                 `json:"imageUrl"`
+	Classes             []string               `json:"classes"`
+	Note                string                 `json:"note"`
+	Address             string                 `json:"address"`
+	Location            *Location              `json:"location"`
+	DateReported        time.Time              `json:"dateReported"`
+	CanonicalID         *int                   `json:"canonicalId,omitempty"`
+	DuplicateCount      int                    `json:"duplicateCount"`
+	ConfirmationCount   int                    `json:"confirmationCount"`
+	DeletedAt           *time.Time             `json:"deletedAt,omitempty"`
+	Score               *float64               `json:"score,omitempty"`
+	ClassScores         map[string]float64     `json:"classScores,omitempty"`
+	Detections          []*ReportDetection     `json:"detections,omitempty"`
+	PredictedClasses    []string               `json:"predictedClasses"`
+	PredictedDetections []*ReportDetection     `json:"predictedDetections,omitempty"`
+	RelabeledAt         *time.Time             `json:"relabeledAt,omitempty"`
+	DetectionsRelabeled bool                   `json:"-"`
+	ModelVersion        *string                `json:"modelVersion,omitempty"`
+	Distance            *float64               `json:"distance,omitempty"`
+	Relevance           *float64               `json:"relevance,omitempty"`
+	StatusHistory       []*ReportStatusHistory `json:"statusHistory,omitempty"`
 }
 
 type Location struct {
@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+type ReportComment struct {
+	ID         int       `json:"id"`
+	ReportID   int       `json:"reportId"`
+	UserID     int       `json:"-"`
+	AuthorName string    `json:"authorName"`
+	AuthorRole string    `json:"authorRole"`
+	Body       string    `json:"body"`
+	Internal   bool      `json:"internal"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
@@ -0,0 +1,26 @@
+package entity
+
+const (
+	DetectionSourceModel = "model"
+	DetectionSourceHuman = "human"
+)
+
+// ReportDetection is a single damage found in a report's photo, either by the
+// model or by an admin correcting it.
+type ReportDetection struct {
+	ID       int     `json:"id"`
+	ReportID int     `json:"-"`
+	Class    string  `json:"class"`
+	Score    float64 `json:"score"`
+	Box      *Box    `json:"box"`
+	Source   string  `json:"source,omitempty"`
+}
+
+// Box is a bounding box in pixels of the original photo, with the origin at
+// the top left corner.
+type Box struct {
+	XMin float64 `json:"xMin"`
+	YMin float64 `json:"yMin"`
+	XMax float64 `json:"xMax"`
+	YMax float64 `json:"yMax"`
+}
@@ -0,0 +1,19 @@
+package entity
+
+import "time"
+
+// ReportPrediction is a prediction made on a report's photo by another model
+// than the one the report was created with, either by a backfill or by the
+// shadow model while the report was submitted.
+type ReportPrediction struct {
+	ID           int                `json:"id"`
+	ReportID     int                `json:"reportId"`
+	BackfillID   *int               `json:"backfillId,omitempty"`
+	ModelVersion string             `json:"modelVersion"`
+	Shadow       bool               `json:"shadow"`
+	Classes      []string           `json:"classes"`
+	Score        *float64           `json:"score,omitempty"`
+	ClassScores  map[string]float64 `json:"classScores,omitempty"`
+	Detections   []*ReportDetection `json:"detections,omitempty"`
+	CreatedAt    time.Time          `json:"createdAt"`
+}
@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+type ReportStatusHistory struct {
+	ID        int       `json:"id"`
+	ReportID  int       `json:"reportId"`
+	UserID    *int      `json:"-"`
+	ActorName string    `json:"actorName"`
+	OldStatus string    `json:"oldStatus"`
+	NewStatus string    `json:"newStatus"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"createdAt"`
+}
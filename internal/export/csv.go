@@ -0,0 +1,34 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func NewCSVWriter(w io.Writer) RowWriter {
+	return &csvWriter{
+		w: csv.NewWriter(w),
+	}
+}
+
+func (c *csvWriter) WriteRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for k, v := range values {
+		record[k] = formatValue(v)
+		if _, ok := v.(string); ok {
+			record[k] = escapeFormula(record[k])
+		}
+	}
+
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+
+	return c.w.Error()
+}
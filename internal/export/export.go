@@ -0,0 +1,43 @@
+package export
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RowWriter writes tabular data one row at a time so large exports never have
+// to be held in memory.
+type RowWriter interface {
+	WriteRow(values []interface{}) error
+	Close() error
+}
+
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// escapeFormula keeps spreadsheets opening a CSV file from evaluating user
+// input, such as a note starting with "=", as a formula. XLSX cells are
+// written as inline strings, which are never evaluated.
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+
+	return s
+}
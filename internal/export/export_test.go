@@ -0,0 +1,212 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestXLSXWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := NewXLSXWriter(buf)
+
+	rows := [][]interface{}{
+		{"id", "address", "lat"},
+		{1, "Jalan <Sudirman> & Thamrin", -6.175392},
+		{2, time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), 106.827153},
+	}
+	for _, row := range rows {
+		if err := w.WriteRow(row); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Expecting a valid zip archive, but got %v instead", err)
+	}
+
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		b, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(b)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		if _, ok := files[name]; !ok {
+			t.Errorf("Expecting archive to contain %q", name)
+		}
+	}
+
+	sheet := files["xl/worksheets/sheet1.xml"]
+	if got := strings.Count(sheet, "<row>"); got != len(rows) {
+		t.Errorf("Expecting %d rows but got %d instead", len(rows), got)
+	}
+
+	if !strings.Contains(sheet, "Jalan &lt;Sudirman&gt; &amp; Thamrin") {
+		t.Error("Expecting text to be escaped")
+	}
+
+	if !strings.Contains(sheet, "<c><v>-6.175392</v></c>") {
+		t.Error("Expecting floats to be written as numeric cells")
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := NewCSVWriter(buf)
+
+	w.WriteRow([]interface{}{"id", "classes"})
+	w.WriteRow([]interface{}{1, "D00,D40"})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "id,classes\n1,\"D00,D40\"\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Expecting %q but got %q instead", want, got)
+	}
+}
+
+func TestFormulaEscaping(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := NewCSVWriter(buf)
+
+	w.WriteRow([]interface{}{"=1+1", "+1", "-1", "@SUM(A1)", "\tx", "\rx", "jalan -", -6.5})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "'=1+1,'+1,'-1,'@SUM(A1),'\tx,\"'\rx\",jalan -,-6.5\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Expecting %q but got %q instead", want, got)
+	}
+
+	buf.Reset()
+	x := NewXLSXWriter(buf)
+	x.WriteRow([]interface{}{"=1+1"})
+	if err := x.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Expecting a valid zip archive, but got %v instead", err)
+	}
+	for _, f := range zr.File {
+		if f.Name != "xl/worksheets/sheet1.xml" {
+			continue
+		}
+		rc, _ := f.Open()
+		b, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		if sheet := string(b); !strings.Contains(sheet, `<t xml:space="preserve">=1+1</t>`) {
+			t.Errorf("Expecting inline string cells to be left as is but got %s instead", sheet)
+		}
+	}
+}
+
+func TestKMLWriter(t *testing.T) {
+	buf := new(bytes.Buffer)
+	w := NewKMLWriter(buf, "Reports", []*KMLStyle{
+		{ID: "reported", IconColor: "ff0000ff", IconHref: "http://maps.google.com/mapfiles/kml/shapes/caution.png"},
+	})
+
+	w.WritePlacemark(&KMLPlacemark{
+		Name:        "Report #1",
+		Description: `<img src="https://storage.googleapis.com/test/predict.jpg"/>`,
+		StyleURL:    "#reported",
+		Lng:         110.663314,
+		Lat:         -7.666369,
+	})
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := struct {
+		Document struct {
+			Name       string `xml:"name"`
+			Styles     []KMLStyle
+			Placemarks []KMLPlacemark `xml:"Placemark"`
+		}
+	}{}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Expecting a valid KML document, but got %v instead", err)
+	}
+
+	if len(doc.Document.Placemarks) != 1 {
+		t.Fatalf("Expecting 1 placemark but got %d instead", len(doc.Document.Placemarks))
+	}
+
+	placemark := doc.Document.Placemarks[0]
+	if placemark.Coordinates != "110.663314,-7.666369" {
+		t.Errorf("Expecting coordinates to be in lng,lat order but got %q instead", placemark.Coordinates)
+	}
+
+	if !strings.Contains(placemark.Description, "predict.jpg") {
+		t.Error("Expecting description to contain the image url")
+	}
+}
+
+func TestYOLOWriter(t *testing.T) {
+	img := new(bytes.Buffer)
+	if err := png.Encode(img, image.NewGray(image.Rect(0, 0, 200, 100))); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := new(bytes.Buffer)
+	w := NewYOLOWriter(buf, []string{"D00", "D40"})
+	if err := w.WriteSample("report-1", img.Bytes(), []*YOLOObject{
+		{Class: "D40", XMin: 50, YMin: 25, XMax: 150, YMax: 75},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteSample("report-2", img.Bytes(), []*YOLOObject{
+		{Class: "D99", XMin: 0, YMin: 0, XMax: 10, YMax: 10},
+	}); err == nil {
+		t.Error("Expecting an error for an unknown class")
+	}
+	if err := w.WriteSample("report-3", []byte("not an image"), nil); err == nil {
+		t.Error("Expecting an error for an invalid image")
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Expecting a valid zip archive, but got %v instead", err)
+	}
+
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		b, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(b)
+	}
+
+	if _, ok := files["images/report-1.png"]; !ok {
+		t.Error("Expecting archive to contain the image")
+	}
+	if label := files["labels/report-1.txt"]; label != "1 0.500000 0.500000 0.500000 0.500000\n" {
+		t.Errorf("Expecting normalized label but got %q instead", label)
+	}
+	if files["classes.txt"] != "D00\nD40\n" {
+		t.Errorf("Expecting classes in order but got %q instead", files["classes.txt"])
+	}
+	if !strings.Contains(files["data.yaml"], "nc: 2") {
+		t.Errorf("Expecting data.yaml to contain the number of classes but got %q instead", files["data.yaml"])
+	}
+}
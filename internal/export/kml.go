@@ -0,0 +1,84 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+const KMLContentType = "application/vnd.google-earth.kml+xml"
+
+type KMLStyle struct {
+	XMLName   xml.Name `xml:"Style"`
+	ID        string   `xml:"id,attr"`
+	IconColor string   `xml:"IconStyle>color"`
+	IconScale float64  `xml:"IconStyle>scale,omitempty"`
+	IconHref  string   `xml:"IconStyle>Icon>href"`
+}
+
+type KMLPlacemark struct {
+	XMLName     xml.Name `xml:"Placemark"`
+	ID          string   `xml:"id,attr,omitempty"`
+	Name        string   `xml:"name"`
+	Description string   `xml:"description"`
+	StyleURL    string   `xml:"styleUrl,omitempty"`
+	Lng         float64  `xml:"-"`
+	Lat         float64  `xml:"-"`
+	Coordinates string   `xml:"Point>coordinates"`
+}
+
+// KMLWriter streams placemarks into a single KML document.
+type KMLWriter struct {
+	w   io.Writer
+	enc *xml.Encoder
+	err error
+}
+
+func NewKMLWriter(w io.Writer, name string, styles []*KMLStyle) *KMLWriter {
+	k := &KMLWriter{
+		w:   w,
+		enc: xml.NewEncoder(w),
+	}
+
+	if _, k.err = io.WriteString(w, xml.Header+`<kml xmlns="http://www.opengis.net/kml/2.2"><Document><name>`); k.err != nil {
+		return k
+	}
+	if k.err = xml.EscapeText(w, []byte(name)); k.err != nil {
+		return k
+	}
+	if _, k.err = io.WriteString(w, `</name>`); k.err != nil {
+		return k
+	}
+	for _, style := range styles {
+		if k.err = k.enc.Encode(style); k.err != nil {
+			return k
+		}
+	}
+
+	return k
+}
+
+func (k *KMLWriter) WritePlacemark(placemark *KMLPlacemark) error {
+	if k.err != nil {
+		return k.err
+	}
+
+	placemark.Coordinates = fmt.Sprintf("%f,%f", placemark.Lng, placemark.Lat)
+	k.err = k.enc.Encode(placemark)
+
+	return k.err
+}
+
+func (k *KMLWriter) Close() error {
+	if k.err != nil {
+		return k.err
+	}
+
+	if err := k.enc.Flush(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(k.w, `</Document></kml>`)
+
+	return err
+}
@@ -0,0 +1,112 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"strings"
+)
+
+const ZipContentType = "application/zip"
+
+// YOLOObject is a labelled bounding box in pixels of its image.
+type YOLOObject struct {
+	Class string
+	XMin  float64
+	YMin  float64
+	XMax  float64
+	YMax  float64
+}
+
+// YOLOWriter streams images and their labels into a zip archive laid out as a
+// YOLO dataset, with one label file per image under labels/.
+type YOLOWriter struct {
+	zw      *zip.Writer
+	classes []string
+	index   map[string]int
+}
+
+func NewYOLOWriter(w io.Writer, classes []string) *YOLOWriter {
+	index := make(map[string]int, len(classes))
+	for i, class := range classes {
+		index[class] = i
+	}
+
+	return &YOLOWriter{
+		zw:      zip.NewWriter(w),
+		classes: classes,
+		index:   index,
+	}
+}
+
+// WriteSample adds an image and its objects to the archive. Coordinates are
+// normalized against the image size, which is read from its header.
+func (y *YOLOWriter) WriteSample(name string, img []byte, objects []*YOLOObject) error {
+	config, format, err := image.DecodeConfig(bytes.NewReader(img))
+	if err != nil {
+		return fmt.Errorf("decode image %s: %w", name, err)
+	}
+	if config.Width == 0 || config.Height == 0 {
+		return fmt.Errorf("decode image %s: empty image", name)
+	}
+	width, height := float64(config.Width), float64(config.Height)
+
+	labels := new(strings.Builder)
+	for _, object := range objects {
+		class, ok := y.index[object.Class]
+		if !ok {
+			return fmt.Errorf("unknown class %q in %s", object.Class, name)
+		}
+
+		fmt.Fprintf(
+			labels,
+			"%d %.6f %.6f %.6f %.6f\n",
+			class,
+			(object.XMin+object.XMax)/2/width,
+			(object.YMin+object.YMax)/2/height,
+			(object.XMax-object.XMin)/width,
+			(object.YMax-object.YMin)/height,
+		)
+	}
+
+	if format == "jpeg" {
+		format = "jpg"
+	}
+	if err := y.writeFile(fmt.Sprintf("images/%s.%s", name, format), img); err != nil {
+		return err
+	}
+
+	return y.writeFile(fmt.Sprintf("labels/%s.txt", name), []byte(labels.String()))
+}
+
+// Close writes the class names and finishes the archive.
+func (y *YOLOWriter) Close() error {
+	if err := y.writeFile("classes.txt", []byte(strings.Join(y.classes, "\n")+"\n")); err != nil {
+		return err
+	}
+
+	data := new(strings.Builder)
+	fmt.Fprintf(data, "path: .\ntrain: images\nval: images\nnc: %d\nnames:\n", len(y.classes))
+	for _, class := range y.classes {
+		fmt.Fprintf(data, "  - %s\n", class)
+	}
+	if err := y.writeFile("data.yaml", []byte(data.String())); err != nil {
+		return err
+	}
+
+	return y.zw.Close()
+}
+
+func (y *YOLOWriter) writeFile(name string, data []byte) error {
+	f, err := y.zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+
+	return err
+}
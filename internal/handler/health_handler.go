@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/service"
+)
+
+type HealthHandler struct {
+	Predictor service.PredictorHealthReporter
+}
+
+func NewHealthHandler(predictor service.PredictorHealthReporter) *HealthHandler {
+	return &HealthHandler{
+		Predictor: predictor,
+	}
+}
+
+func (h *HealthHandler) Route(mux *chi.Mux) {
+	mux.Get("/api/health", h.GetHealth)
+}
+
+// GetHealth reports the state of the dependencies of the API. It answers 200
+// OK even when the predict service is failing, since every other endpoint
+// still works.
+func (h *HealthHandler) GetHealth(w http.ResponseWriter, r *http.Request) {
+	health := &model.Health{
+		Predictor: h.Predictor.Health(),
+	}
+
+	api.NewResponse(http.StatusOK, "OK", health).SendJSON(w)
+}
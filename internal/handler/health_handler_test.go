@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/service"
+)
+
+func TestHealthHandler(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/health", nil)
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	assertResponseCode(t, http.StatusOK, res.Code)
+
+	resBody, _ := ioutil.ReadAll(res.Body)
+	apiResponse := struct {
+		Data *model.Health `json:"data"`
+	}{}
+	json.Unmarshal(resBody, &apiResponse)
+
+	if apiResponse.Data.Predictor == nil || apiResponse.Data.Predictor.State != service.CircuitClosed {
+		t.Errorf("Expecting a closed predictor circuit but got %+v instead", apiResponse.Data.Predictor)
+	}
+}
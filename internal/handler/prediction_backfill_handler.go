@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/middleware"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/service"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/validation"
+)
+
+type PredictionBackfillHandler struct {
+	*validation.Validator
+	service.PredictionBackfillService
+}
+
+func NewPredictionBackfillHandler(
+	val *validation.Validator,
+	backfillSRV service.PredictionBackfillService,
+) *PredictionBackfillHandler {
+	return &PredictionBackfillHandler{
+		Validator:                 val,
+		PredictionBackfillService: backfillSRV,
+	}
+}
+
+func (h *PredictionBackfillHandler) Route(mux *chi.Mux) {
+	mux.Route("/api/backfills", func(r chi.Router) {
+		r.Use(middleware.RequireAuth, middleware.RequireAdmin)
+		r.Post("/", h.NewBackfill)
+		r.Get("/", h.GetAllBackfill)
+		r.Get("/{backfillID}", h.GetBackfill)
+		r.Post("/{backfillID}/pause", h.PauseBackfill)
+		r.Post("/{backfillID}/resume", h.ResumeBackfill)
+	})
+	mux.Route("/api/reports/{reportID}/predictions", func(r chi.Router) {
+		r.Use(middleware.RequireAuth, middleware.RequireAdmin)
+		r.Get("/", h.GetReportPredictions)
+	})
+}
+
+// NewBackfill re-runs prediction on the reports matching the report filter in
+// the query string, optionally narrowed down to the report ids in the body.
+func (h *PredictionBackfillHandler) NewBackfill(w http.ResponseWriter, r *http.Request) {
+	const op = "PredictionBackfillHandler.NewBackfill"
+	userPayload, err := api.UserPayloadFromContext(op, r)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	filter, err := parseReportFilter(op, r.URL.Query())
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	if err := h.Validate(op, filter); err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	createPredictionBackfillDTO := new(model.CreatePredictionBackfillDTO)
+	if err := api.Bind(r.Body, createPredictionBackfillDTO); err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	if err := h.Validate(op, createPredictionBackfillDTO); err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	backfill, err := h.PredictionBackfillService.Create(r.Context(), userPayload.ID, filter, createPredictionBackfillDTO)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	api.NewResponse(http.StatusAccepted, "Accepted", backfill).SendJSON(w)
+}
+
+func (h *PredictionBackfillHandler) GetAllBackfill(w http.ResponseWriter, r *http.Request) {
+	backfills, err := h.PredictionBackfillService.GetAll(r.Context())
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	api.NewResponse(http.StatusOK, "OK", backfills).SendJSON(w)
+}
+
+func (h *PredictionBackfillHandler) GetBackfill(w http.ResponseWriter, r *http.Request) {
+	const op = "PredictionBackfillHandler.GetBackfill"
+	backfillID, err := parseBackfillID(op, r)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	backfill, err := h.PredictionBackfillService.Get(r.Context(), backfillID)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	api.NewResponse(http.StatusOK, "OK", backfill).SendJSON(w)
+}
+
+func (h *PredictionBackfillHandler) PauseBackfill(w http.ResponseWriter, r *http.Request) {
+	const op = "PredictionBackfillHandler.PauseBackfill"
+	backfillID, err := parseBackfillID(op, r)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	backfill, err := h.PredictionBackfillService.Pause(r.Context(), backfillID)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	api.NewResponse(http.StatusOK, "OK", backfill).SendJSON(w)
+}
+
+func (h *PredictionBackfillHandler) ResumeBackfill(w http.ResponseWriter, r *http.Request) {
+	const op = "PredictionBackfillHandler.ResumeBackfill"
+	backfillID, err := parseBackfillID(op, r)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	backfill, err := h.PredictionBackfillService.Resume(r.Context(), backfillID)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	api.NewResponse(http.StatusOK, "OK", backfill).SendJSON(w)
+}
+
+func (h *PredictionBackfillHandler) GetReportPredictions(w http.ResponseWriter, r *http.Request) {
+	const op = "PredictionBackfillHandler.GetReportPredictions"
+	reportIDParam := chi.URLParam(r, "reportID")
+	reportID, err := strconv.Atoi(reportIDParam)
+	if err != nil {
+		exc := api.NewSingleMessageException(
+			api.EINVALID,
+			op,
+			"Invalid report id",
+			err,
+		)
+		api.SendError(w, exc)
+		return
+	}
+
+	predictions, err := h.PredictionBackfillService.GetPredictions(r.Context(), reportID)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	api.NewResponse(http.StatusOK, "OK", predictions).SendJSON(w)
+}
+
+func parseBackfillID(op string, r *http.Request) (int, error) {
+	backfillID, err := strconv.Atoi(chi.URLParam(r, "backfillID"))
+	if err != nil {
+		return 0, api.NewSingleMessageException(
+			api.EINVALID,
+			op,
+			"Invalid backfill id",
+			err,
+		)
+	}
+
+	return backfillID, nil
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/middleware"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/service"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/validation"
+)
+
+type ReportCommentHandler struct {
+	*validation.Validator
+	service.ReportCommentService
+}
+
+func NewReportCommentHandler(
+	val *validation.Validator,
+	commentSRV service.ReportCommentService,
+) *ReportCommentHandler {
+	return &ReportCommentHandler{
+		Validator:            val,
+		ReportCommentService: commentSRV,
+	}
+}
+
+func (h *ReportCommentHandler) Route(mux *chi.Mux) {
+	mux.Route("/api/reports/{reportID}/comments", func(r chi.Router) {
+		r.Use(middleware.RequireAuth)
+		r.Post("/", h.NewComment)
+		r.Get("/", h.GetAllComment)
+	})
+}
+
+func (h *ReportCommentHandler) NewComment(w http.ResponseWriter, r *http.Request) {
+	const op = "ReportCommentHandler.NewComment"
+	userPayload, err := api.UserPayloadFromContext(op, r)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	reportIDParam := chi.URLParam(r, "reportID")
+	reportID, err := strconv.Atoi(reportIDParam)
+	if err != nil {
+		exc := api.NewSingleMessageException(
+			api.EINVALID,
+			op,
+			"Invalid report id",
+			err,
+		)
+		api.SendError(w, exc)
+		return
+	}
+
+	createReportCommentDTO := new(model.CreateReportCommentDTO)
+	if err := api.Bind(r.Body, createReportCommentDTO); err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	if err := h.Validate(op, createReportCommentDTO); err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	comment, err := h.ReportCommentService.Create(r.Context(), userPayload, reportID, createReportCommentDTO)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	api.NewResponse(http.StatusCreated, "Created", comment).SendJSON(w)
+}
+
+func (h *ReportCommentHandler) GetAllComment(w http.ResponseWriter, r *http.Request) {
+	const op = "ReportCommentHandler.GetAllComment"
+	userPayload, err := api.UserPayloadFromContext(op, r)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	reportIDParam := chi.URLParam(r, "reportID")
+	reportID, err := strconv.Atoi(reportIDParam)
+	if err != nil {
+		exc := api.NewSingleMessageException(
+			api.EINVALID,
+			op,
+			"Invalid report id",
+			err,
+		)
+		api.SendError(w, exc)
+		return
+	}
+
+	comments, err := h.ReportCommentService.GetAllByReportID(r.Context(), userPayload, reportID)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	api.NewResponse(http.StatusOK, "OK", comments).SendJSON(w)
+}
@@ -0,0 +1,293 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/export"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/logger"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+)
+
+var reportExportHeader = []interface{}{
+	"id",
+	"reporter_name",
+	"status",
+	"classes",
+	"lat",
+	"lng",
+	"address",
+	"note",
+	"image_url",
+	"date_reported",
+}
+
+func reportExportRow(report *entity.Report) []interface{} {
+	return []interface{}{
+		report.ID,
+		report.ReporterName,
+		report.Status,
+		strings.Join(report.Classes, ","),
+		report.Location.Lat,
+		report.Location.Lng,
+		report.Address,
+		report.Note,
+		report.ImageURL,
+		report.DateReported,
+	}
+}
+
+func (h *ReportHandler) ExportReports(w http.ResponseWriter, r *http.Request) {
+	const op = "ReportHandler.ExportReports"
+	filter, err := parseReportFilter(op, r.URL.Query())
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	if err := h.Validate(op, filter); err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	var contentType string
+	var newWriter func() export.RowWriter
+	switch format {
+	case "", "csv":
+		format = "csv"
+		contentType = "text/csv; charset=utf-8"
+		newWriter = func() export.RowWriter { return export.NewCSVWriter(w) }
+	case "xlsx":
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+		newWriter = func() export.RowWriter { return export.NewXLSXWriter(w) }
+	default:
+		exc := api.NewSingleMessageException(
+			api.EINVALID,
+			op,
+			"Invalid format argument. format must be csv or xlsx",
+			errors.New("invalid export format"),
+		)
+		api.SendError(w, exc)
+		return
+	}
+
+	var rowWriter export.RowWriter
+	res := newExportResponse(w, contentType, exportFilename(format), func() {
+		rowWriter = newWriter()
+		rowWriter.WriteRow(reportExportHeader)
+	})
+
+	err = h.ReportService.Export(r.Context(), filter, func(report *entity.Report) error {
+		res.start()
+		return rowWriter.WriteRow(reportExportRow(report))
+	})
+	res.finish(op, err, func() error {
+		return rowWriter.Close()
+	})
+}
+
+func (h *ReportHandler) ExportReportsKML(w http.ResponseWriter, r *http.Request) {
+	const op = "ReportHandler.ExportReportsKML"
+	filter, err := parseReportFilter(op, r.URL.Query())
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	if err := h.Validate(op, filter); err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	var kmlWriter *export.KMLWriter
+	res := newExportResponse(w, export.KMLContentType, exportFilename("kml"), func() {
+		kmlWriter = export.NewKMLWriter(w, "Rodavis Reports", reportKMLStyles())
+	})
+
+	err = h.ReportService.Export(r.Context(), filter, func(report *entity.Report) error {
+		res.start()
+		return kmlWriter.WritePlacemark(reportPlacemark(report))
+	})
+	res.finish(op, err, func() error {
+		return kmlWriter.Close()
+	})
+}
+
+// ExportDataset streams the photos of relabeled reports with their corrected
+// bounding boxes as a YOLO dataset.
+func (h *ReportHandler) ExportDataset(w http.ResponseWriter, r *http.Request) {
+	const op = "ReportHandler.ExportDataset"
+	filter, err := parseReportFilter(op, r.URL.Query())
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	if err := h.Validate(op, filter); err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	var yoloWriter *export.YOLOWriter
+	res := newExportResponse(w, export.ZipContentType, exportFilename("zip"), func() {
+		yoloWriter = export.NewYOLOWriter(w, entity.ReportClasses)
+	})
+
+	skipped, err := h.ReportService.ExportDataset(r.Context(), filter, func(report *entity.Report, image []byte) error {
+		res.start()
+		objects := make([]*export.YOLOObject, 0, len(report.Detections))
+		for _, detection := range report.Detections {
+			objects = append(objects, &export.YOLOObject{
+				Class: detection.Class,
+				XMin:  detection.Box.XMin,
+				YMin:  detection.Box.YMin,
+				XMax:  detection.Box.XMax,
+				YMax:  detection.Box.YMax,
+			})
+		}
+
+		return yoloWriter.WriteSample(fmt.Sprintf("report-%d", report.ID), image, objects)
+	})
+	if skipped > 0 {
+		logger.NewWarn().
+			Str("op", op).
+			Msg(fmt.Sprintf("Left %d reports out of the dataset because their photo could not be downloaded", skipped))
+	}
+	res.finish(op, err, func() error {
+		return yoloWriter.Close()
+	})
+}
+
+func exportFilename(format string) string {
+	return fmt.Sprintf("reports-%s.%s", time.Now().Format("20060102"), format)
+}
+
+// exportResponse defers writing the response headers until the first report
+// arrives, so failures before that point still get a JSON error response.
+type exportResponse struct {
+	w           http.ResponseWriter
+	contentType string
+	filename    string
+	open        func()
+	started     bool
+}
+
+func newExportResponse(w http.ResponseWriter, contentType, filename string, open func()) *exportResponse {
+	return &exportResponse{
+		w:           w,
+		contentType: contentType,
+		filename:    filename,
+		open:        open,
+	}
+}
+
+func (e *exportResponse) start() {
+	if e.started {
+		return
+	}
+
+	e.w.Header().Set("Content-Type", e.contentType)
+	e.w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", e.filename))
+	e.w.WriteHeader(http.StatusOK)
+	e.open()
+	e.started = true
+}
+
+func (e *exportResponse) finish(op string, err error, close func() error) {
+	if err != nil {
+		if !e.started {
+			api.SendError(e.w, err)
+			return
+		}
+
+		// The status line is already sent, all that can be done is to log
+		// the failure and leave the client with a truncated file.
+		logger.Error(op, &model.SourceLocation{Function: "h.ReportService.Export"}, err)
+		return
+	}
+
+	e.start()
+	if err := close(); err != nil {
+		logger.Error(op, &model.SourceLocation{Function: "close"}, err)
+	}
+}
+
+var kmlStatusColors = map[string]string{
+	"Reported":     "ff0000ff",
+	"Under Repair": "ff00a5ff",
+	"Completed":    "ff00b000",
+	"Rejected":     "ff808080",
+}
+
+// kmlClassIcons groups the damage classes into longitudinal and lateral
+// cracks, alligator cracks, potholes, blurred markings and manholes.
+var kmlClassIcons = map[string]string{
+	"D00":  "http://maps.google.com/mapfiles/kml/shapes/placemark_circle.png",
+	"D01":  "http://maps.google.com/mapfiles/kml/shapes/placemark_circle.png",
+	"D10":  "http://maps.google.com/mapfiles/kml/shapes/placemark_circle.png",
+	"D11":  "http://maps.google.com/mapfiles/kml/shapes/placemark_circle.png",
+	"D20":  "http://maps.google.com/mapfiles/kml/shapes/polygon.png",
+	"D40":  "http://maps.google.com/mapfiles/kml/shapes/caution.png",
+	"D43":  "http://maps.google.com/mapfiles/kml/shapes/placemark_square.png",
+	"D44":  "http://maps.google.com/mapfiles/kml/shapes/placemark_square.png",
+	"D50":  "http://maps.google.com/mapfiles/kml/shapes/target.png",
+	"none": "http://maps.google.com/mapfiles/kml/shapes/open-diamond.png",
+}
+
+var kmlClasses = []string{"D00", "D01", "D10", "D11", "D20", "D40", "D43", "D44", "D50", "none"}
+
+var kmlStatuses = []string{"Reported", "Under Repair", "Completed", "Rejected"}
+
+func reportKMLStyleID(status, class string) string {
+	return strings.ToLower(strings.ReplaceAll(status, " ", "-")) + "-" + strings.ToLower(class)
+}
+
+func reportKMLStyles() []*export.KMLStyle {
+	styles := []*export.KMLStyle{}
+	for _, status := range kmlStatuses {
+		for _, class := range kmlClasses {
+			styles = append(styles, &export.KMLStyle{
+				ID:        reportKMLStyleID(status, class),
+				IconColor: kmlStatusColors[status],
+				IconScale: 1.1,
+				IconHref:  kmlClassIcons[class],
+			})
+		}
+	}
+
+	return styles
+}
+
+func reportPlacemark(report *entity.Report) *export.KMLPlacemark {
+	class := "none"
+	if len(report.Classes) > 0 {
+		class = report.Classes[0]
+	}
+
+	description := fmt.Sprintf(
+		`<img src="%s" width="320"/><br/><b>Status:</b> %s<br/><b>Classes:</b> %s<br/><b>Address:</b> %s<br/><b>Note:</b> %s<br/><b>Reported:</b> %s by %s`,
+		html.EscapeString(report.ImageURL),
+		html.EscapeString(report.Status),
+		html.EscapeString(strings.Join(report.Classes, ", ")),
+		html.EscapeString(report.Address),
+		html.EscapeString(report.Note),
+		report.DateReported.Format("2006-01-02 15:04"),
+		html.EscapeString(report.ReporterName),
+	)
+
+	return &export.KMLPlacemark{
+		ID:          fmt.Sprintf("report-%d", report.ID),
+		Name:        fmt.Sprintf("#%d %s", report.ID, report.Address),
+		Description: description,
+		StyleURL:    "#" + reportKMLStyleID(report.Status, class),
+		Lng:         report.Location.Lng,
+		Lat:         report.Location.Lat,
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/annotate"
 	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
 	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
 	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/middleware"
@@ -15,6 +16,16 @@ import (
 	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/validation"
 )
 
+// Annotated images only change when detections are relabelled, so reviewers'
+// browsers can revalidate them with the ETag.
+const annotatedImageCacheControl = "private, no-cache"
+
+// reviewMessages tell reporters why their report is not published yet.
+var reviewMessages = map[string]string{
+	entity.ReviewReasonNoDamage:      "No road damage was detected in the photo. An admin will review your report before it is published",
+	entity.ReviewReasonLowConfidence: "The road damage in the photo could not be recognised with enough confidence. An admin will review your report before it is published",
+}
+
 type ReportHandler struct {
 	*validation.Validator
 	service.ReportService
@@ -35,7 +46,26 @@ func (h *ReportHandler) Route(mux *chi.Mux) {
 		r.With(middleware.RequireAuth).Post("/", h.NewReport)
 		r.Get("/", h.GetAllReport)
 		r.With(middleware.RequireAuth).Get("/history", h.GetAllUserReport)
+		r.With(middleware.RequireAuth, middleware.RequireAdmin).Get("/stats", h.GetReportStats)
+		r.With(middleware.RequireAuth, middleware.RequireAdmin).Get("/shadow-agreement", h.GetShadowAgreement)
+		r.With(middleware.RequireAuth, middleware.RequireAdmin).Get("/withdrawn", h.GetWithdrawnReports)
+		r.With(middleware.RequireAuth, middleware.RequireAdmin).Get("/review", h.GetReviewQueue)
+		r.Get("/clusters", h.GetReportClusters)
+		r.With(middleware.RequireAuth, middleware.RequireAdmin).Get("/export", h.ExportReports)
+		r.With(middleware.RequireAuth, middleware.RequireAdmin).Get("/export.kml", h.ExportReportsKML)
+		r.With(middleware.RequireAuth, middleware.RequireAdmin).Get("/dataset", h.ExportDataset)
+		r.With(middleware.OptionalAuth).Get("/{reportID}", h.GetReport)
+		r.With(middleware.RequireAuth).Get("/{reportID}/history", h.GetReportStatusHistory)
+		r.With(middleware.RequireAuth, middleware.RequireAdmin).Get("/{reportID}/annotated.jpg", h.GetAnnotatedImage)
 		r.With(middleware.RequireAuth).Put("/{reportID}", h.UpdateReport)
+		r.With(middleware.RequireAuth).Patch("/{reportID}", h.EditReport)
+		r.With(middleware.RequireAuth).Delete("/{reportID}", h.WithdrawReport)
+		r.With(middleware.RequireAuth, middleware.RequireAdmin).Post("/{reportID}/restore", h.RestoreReport)
+		r.With(middleware.RequireAuth, middleware.RequireAdmin).Post("/{reportID}/merge", h.MergeReport)
+		r.With(middleware.RequireAuth, middleware.RequireAdmin).Post("/{reportID}/split", h.SplitReport)
+		r.With(middleware.RequireAuth, middleware.RequireAdmin).Put("/{reportID}/labels", h.RelabelReport)
+		r.With(middleware.RequireAuth).Post("/{reportID}/confirm", h.ConfirmReport)
+		r.With(middleware.RequireAuth).Delete("/{reportID}/confirm", h.UnconfirmReport)
 	})
 }
 
@@ -117,7 +147,12 @@ func (h *ReportHandler) NewReport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	api.NewResponse(http.StatusCreated, "Created", report).SendJSON(w)
+	message := "Created"
+	if report.Status == service.StatusNeedsReview {
+		message = reviewMessages[report.ReviewReason]
+	}
+
+	api.NewResponse(http.StatusCreated, message, report).SendJSON(w)
 }
 
 func (h *ReportHandler) GetAllReport(w http.ResponseWriter, r *http.Request) {
@@ -156,17 +191,59 @@ func (h *ReportHandler) GetAllReport(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	filter, err := parseReportFilter(op, r.URL.Query())
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	if err := h.Validate(op, filter); err != nil {
+		api.SendError(w, err)
+		return
+	}
+
 	pagination := &model.Pagination{
 		Limit:      limit,
 		LastseenID: lastseenID,
 	}
-	reports, err := h.ReportService.GetAll(r.Context(), pagination)
+	reports, facets, err := h.ReportService.GetAll(r.Context(), pagination, filter)
 	if err != nil {
 		api.SendError(w, err)
 		return
 	}
 
-	api.NewResponse(http.StatusOK, "OK", reports).SendJSON(w)
+	if api.WantsGeoJSON(r) {
+		reportsToFeatureCollection(reports).SendGeoJSON(w, http.StatusOK)
+		return
+	}
+
+	api.NewResponse(http.StatusOK, "OK", reports).WithMeta(facets).SendJSON(w)
+}
+
+func (h *ReportHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	const op = "ReportHandler.GetReport"
+	reportIDParam := chi.URLParam(r, "reportID")
+	reportID, err := strconv.Atoi(reportIDParam)
+	if err != nil {
+		exc := api.NewSingleMessageException(
+			api.EINVALID,
+			op,
+			"Invalid report id",
+			err,
+		)
+		api.SendError(w, exc)
+		return
+	}
+
+	// Anonymous requests carry no user payload.
+	userPayload, _ := api.UserPayloadFromContext(op, r)
+	report, err := h.ReportService.Get(r.Context(), userPayload, reportID)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	api.NewResponse(http.StatusOK, "OK", report).SendJSON(w)
 }
 
 func (h *ReportHandler) GetAllUserReport(w http.ResponseWriter, r *http.Request) {
@@ -210,17 +287,154 @@ func (h *ReportHandler) GetAllUserReport(w http.ResponseWriter, r *http.Request)
 		}
 	}
 
+	filter, err := parseReportFilter(op, r.URL.Query())
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	if err := h.Validate(op, filter); err != nil {
+		api.SendError(w, err)
+		return
+	}
+
 	pagination := &model.Pagination{
 		Limit:      limit,
 		LastseenID: lastseenID,
 	}
-	reports, err := h.ReportService.GetAllByUserID(r.Context(), userPayload.ID, pagination)
+	reports, facets, err := h.ReportService.GetAllByUserID(r.Context(), userPayload.ID, pagination, filter)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	if api.WantsGeoJSON(r) {
+		reportsToFeatureCollection(reports).SendGeoJSON(w, http.StatusOK)
+		return
+	}
+
+	api.NewResponse(http.StatusOK, "OK", reports).WithMeta(facets).SendJSON(w)
+}
+
+func (h *ReportHandler) GetReportStats(w http.ResponseWriter, r *http.Request) {
+	const op = "ReportHandler.GetReportStats"
+	filter, err := parseReportFilter(op, r.URL.Query())
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	if err := h.Validate(op, filter); err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	interval := r.URL.Query().Get("interval")
+	switch interval {
+	case "":
+		interval = "day"
+	case "day", "week", "month":
+	default:
+		exc := api.NewSingleMessageException(
+			api.EINVALID,
+			op,
+			"Invalid interval argument. interval must be one of day, week or month",
+			errors.New("invalid interval argument"),
+		)
+		api.SendError(w, exc)
+		return
+	}
+
+	stats, err := h.ReportService.GetStats(r.Context(), filter, interval)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	api.NewResponse(http.StatusOK, "OK", stats).SendJSON(w)
+}
+
+// GetShadowAgreement compares a shadow model version with the primary model on
+// the reports matching the filter.
+func (h *ReportHandler) GetShadowAgreement(w http.ResponseWriter, r *http.Request) {
+	const op = "ReportHandler.GetShadowAgreement"
+	filter, err := parseReportFilter(op, r.URL.Query())
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	if err := h.Validate(op, filter); err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	// Versions are compared one at a time, since pooling them would count
+	// the same report once for every candidate.
+	modelVersion := r.URL.Query().Get("modelVersion")
+	if modelVersion == "" {
+		exc := api.NewSingleMessageException(
+			api.EINVALID,
+			op,
+			"modelVersion argument is required",
+			errors.New("missing modelVersion argument"),
+		)
+		api.SendError(w, exc)
+		return
+	}
+
+	agreement, err := h.ReportService.GetShadowAgreement(r.Context(), filter, modelVersion)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	api.NewResponse(http.StatusOK, "OK", agreement).SendJSON(w)
+}
+
+func (h *ReportHandler) GetReportClusters(w http.ResponseWriter, r *http.Request) {
+	const op = "ReportHandler.GetReportClusters"
+	filter, err := parseReportFilter(op, r.URL.Query())
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	if err := h.Validate(op, filter); err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	if filter.Geo == nil || filter.Geo.BBox == nil {
+		exc := api.NewSingleMessageException(
+			api.EINVALID,
+			op,
+			"bbox argument is required",
+			errors.New("missing bbox argument"),
+		)
+		api.SendError(w, exc)
+		return
+	}
+
+	zoom, err := strconv.Atoi(r.URL.Query().Get("zoom"))
+	if err != nil || zoom < 0 || zoom > maxZoom {
+		exc := api.NewSingleMessageException(
+			api.EINVALID,
+			op,
+			"Invalid zoom argument. zoom must be between 0 and 22",
+			errors.New("invalid zoom argument"),
+		)
+		api.SendError(w, exc)
+		return
+	}
+
+	clusters, err := h.ReportService.GetClusters(r.Context(), filter, zoom)
 	if err != nil {
 		api.SendError(w, err)
 		return
 	}
 
-	api.NewResponse(http.StatusOK, "OK", reports).SendJSON(w)
+	api.NewResponse(http.StatusOK, "OK", clusters).SendJSON(w)
 }
 
 func (h *ReportHandler) UpdateReport(w http.ResponseWriter, r *http.Request) {
@@ -266,7 +480,419 @@ func (h *ReportHandler) UpdateReport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	report, err := h.ReportService.Update(r.Context(), updateReportDTO.Status, reportID)
+	report, err := h.ReportService.Update(r.Context(), userPayload.ID, reportID, updateReportDTO)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	api.NewResponse(http.StatusOK, "OK", report).SendJSON(w)
+}
+
+func (h *ReportHandler) GetReportStatusHistory(w http.ResponseWriter, r *http.Request) {
+	const op = "ReportHandler.GetReportStatusHistory"
+	userPayload, err := api.UserPayloadFromContext(op, r)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	reportIDParam := chi.URLParam(r, "reportID")
+	reportID, err := strconv.Atoi(reportIDParam)
+	if err != nil {
+		exc := api.NewSingleMessageException(
+			api.EINVALID,
+			op,
+			"Invalid report id",
+			err,
+		)
+		api.SendError(w, exc)
+		return
+	}
+
+	timeline, err := h.ReportService.GetStatusHistory(r.Context(), userPayload, reportID)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	api.NewResponse(http.StatusOK, "OK", timeline).SendJSON(w)
+}
+
+func (h *ReportHandler) MergeReport(w http.ResponseWriter, r *http.Request) {
+	const op = "ReportHandler.MergeReport"
+	reportIDParam := chi.URLParam(r, "reportID")
+	reportID, err := strconv.Atoi(reportIDParam)
+	if err != nil {
+		exc := api.NewSingleMessageException(
+			api.EINVALID,
+			op,
+			"Invalid report id",
+			err,
+		)
+		api.SendError(w, exc)
+		return
+	}
+
+	mergeReportDTO := new(model.MergeReportDTO)
+	if err := api.Bind(r.Body, mergeReportDTO); err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	if err := h.Validate(op, mergeReportDTO); err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	report, err := h.ReportService.Merge(r.Context(), reportID, mergeReportDTO.CanonicalID)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	api.NewResponse(http.StatusOK, "OK", report).SendJSON(w)
+}
+
+func (h *ReportHandler) SplitReport(w http.ResponseWriter, r *http.Request) {
+	const op = "ReportHandler.SplitReport"
+	reportIDParam := chi.URLParam(r, "reportID")
+	reportID, err := strconv.Atoi(reportIDParam)
+	if err != nil {
+		exc := api.NewSingleMessageException(
+			api.EINVALID,
+			op,
+			"Invalid report id",
+			err,
+		)
+		api.SendError(w, exc)
+		return
+	}
+
+	report, err := h.ReportService.Split(r.Context(), reportID)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	api.NewResponse(http.StatusOK, "OK", report).SendJSON(w)
+}
+
+func (h *ReportHandler) ConfirmReport(w http.ResponseWriter, r *http.Request) {
+	const op = "ReportHandler.ConfirmReport"
+	userPayload, err := api.UserPayloadFromContext(op, r)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	reportIDParam := chi.URLParam(r, "reportID")
+	reportID, err := strconv.Atoi(reportIDParam)
+	if err != nil {
+		exc := api.NewSingleMessageException(
+			api.EINVALID,
+			op,
+			"Invalid report id",
+			err,
+		)
+		api.SendError(w, exc)
+		return
+	}
+
+	report, err := h.ReportService.Confirm(r.Context(), userPayload, reportID)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	api.NewResponse(http.StatusOK, "OK", report).SendJSON(w)
+}
+
+func (h *ReportHandler) UnconfirmReport(w http.ResponseWriter, r *http.Request) {
+	const op = "ReportHandler.UnconfirmReport"
+	userPayload, err := api.UserPayloadFromContext(op, r)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	reportIDParam := chi.URLParam(r, "reportID")
+	reportID, err := strconv.Atoi(reportIDParam)
+	if err != nil {
+		exc := api.NewSingleMessageException(
+			api.EINVALID,
+			op,
+			"Invalid report id",
+			err,
+		)
+		api.SendError(w, exc)
+		return
+	}
+
+	report, err := h.ReportService.Unconfirm(r.Context(), userPayload, reportID)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	api.NewResponse(http.StatusOK, "OK", report).SendJSON(w)
+}
+
+func (h *ReportHandler) GetWithdrawnReports(w http.ResponseWriter, r *http.Request) {
+	const op = "ReportHandler.GetWithdrawnReports"
+	pagination, err := parsePagination(op, r.URL.Query())
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	filter, err := parseReportFilter(op, r.URL.Query())
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	if err := h.Validate(op, filter); err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	filter.Withdrawn = true
+	filter.IncludeDuplicates = true
+	// Reports withdrawn while waiting for review can only be restored from
+	// here.
+	filter.IncludeNeedsReview = true
+	reports, facets, err := h.ReportService.GetAll(r.Context(), pagination, filter)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	api.NewResponse(http.StatusOK, "OK", reports).WithMeta(facets).SendJSON(w)
+}
+
+// GetReviewQueue lists the reports waiting for an admin to approve or reject
+// their prediction with UpdateReport.
+func (h *ReportHandler) GetReviewQueue(w http.ResponseWriter, r *http.Request) {
+	const op = "ReportHandler.GetReviewQueue"
+	pagination, err := parsePagination(op, r.URL.Query())
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	filter, err := parseReportFilter(op, r.URL.Query())
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	if err := h.Validate(op, filter); err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	filter.NeedsReview = true
+	filter.IncludeDuplicates = true
+	reports, facets, err := h.ReportService.GetAll(r.Context(), pagination, filter)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	api.NewResponse(http.StatusOK, "OK", reports).WithMeta(facets).SendJSON(w)
+}
+
+func (h *ReportHandler) EditReport(w http.ResponseWriter, r *http.Request) {
+	const op = "ReportHandler.EditReport"
+	userPayload, err := api.UserPayloadFromContext(op, r)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	reportIDParam := chi.URLParam(r, "reportID")
+	reportID, err := strconv.Atoi(reportIDParam)
+	if err != nil {
+		exc := api.NewSingleMessageException(
+			api.EINVALID,
+			op,
+			"Invalid report id",
+			err,
+		)
+		api.SendError(w, exc)
+		return
+	}
+
+	editReportDTO := new(model.EditReportDTO)
+	if err := api.Bind(r.Body, editReportDTO); err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	if err := h.Validate(op, editReportDTO); err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	report, err := h.ReportService.Edit(r.Context(), userPayload.ID, reportID, editReportDTO)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	api.NewResponse(http.StatusOK, "OK", report).SendJSON(w)
+}
+
+func (h *ReportHandler) WithdrawReport(w http.ResponseWriter, r *http.Request) {
+	const op = "ReportHandler.WithdrawReport"
+	userPayload, err := api.UserPayloadFromContext(op, r)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	reportIDParam := chi.URLParam(r, "reportID")
+	reportID, err := strconv.Atoi(reportIDParam)
+	if err != nil {
+		exc := api.NewSingleMessageException(
+			api.EINVALID,
+			op,
+			"Invalid report id",
+			err,
+		)
+		api.SendError(w, exc)
+		return
+	}
+
+	report, err := h.ReportService.Withdraw(r.Context(), userPayload.ID, reportID)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	api.NewResponse(http.StatusOK, "OK", report).SendJSON(w)
+}
+
+func (h *ReportHandler) RestoreReport(w http.ResponseWriter, r *http.Request) {
+	const op = "ReportHandler.RestoreReport"
+	reportIDParam := chi.URLParam(r, "reportID")
+	reportID, err := strconv.Atoi(reportIDParam)
+	if err != nil {
+		exc := api.NewSingleMessageException(
+			api.EINVALID,
+			op,
+			"Invalid report id",
+			err,
+		)
+		api.SendError(w, exc)
+		return
+	}
+
+	report, err := h.ReportService.Restore(r.Context(), reportID)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	api.NewResponse(http.StatusOK, "OK", report).SendJSON(w)
+}
+
+func (h *ReportHandler) GetAnnotatedImage(w http.ResponseWriter, r *http.Request) {
+	const op = "ReportHandler.GetAnnotatedImage"
+	reportIDParam := chi.URLParam(r, "reportID")
+	reportID, err := strconv.Atoi(reportIDParam)
+	if err != nil {
+		exc := api.NewSingleMessageException(
+			api.EINVALID,
+			op,
+			"Invalid report id",
+			err,
+		)
+		api.SendError(w, exc)
+		return
+	}
+
+	annotated, err := h.ReportService.GetAnnotatedImage(r.Context(), reportID)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	etag := `"` + annotated.ETag + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", annotatedImageCacheControl)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", annotate.ContentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write(annotated.Data)
+}
+
+func reportsToFeatureCollection(reports []*entity.Report) *api.FeatureCollection {
+	features := make([]*api.Feature, len(reports))
+	for k, report := range reports {
+		properties := map[string]interface{}{
+			"reporterName":      report.ReporterName,
+			"status":            report.Status,
+			"classes":           report.Classes,
+			"note":              report.Note,
+			"address":           report.Address,
+			"imageUrl":          report.ImageURL,
+			"dateReported":      report.DateReported,
+			"confirmationCount": report.ConfirmationCount,
+		}
+		if report.Score != nil {
+			properties["score"] = *report.Score
+		}
+		if report.Distance != nil {
+			properties["distance"] = *report.Distance
+		}
+		if report.Relevance != nil {
+			properties["relevance"] = *report.Relevance
+		}
+		features[k] = api.NewPointFeature(report.ID, report.Location.Lng, report.Location.Lat, properties)
+	}
+
+	return api.NewFeatureCollection(features)
+}
+
+func (h *ReportHandler) RelabelReport(w http.ResponseWriter, r *http.Request) {
+	const op = "ReportHandler.RelabelReport"
+	userPayload, err := api.UserPayloadFromContext(op, r)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	reportIDParam := chi.URLParam(r, "reportID")
+	reportID, err := strconv.Atoi(reportIDParam)
+	if err != nil {
+		exc := api.NewSingleMessageException(
+			api.EINVALID,
+			op,
+			"Invalid report id",
+			err,
+		)
+		api.SendError(w, exc)
+		return
+	}
+
+	relabelReportDTO := new(model.RelabelReportDTO)
+	if err := api.Bind(r.Body, relabelReportDTO); err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	if err := h.Validate(op, relabelReportDTO); err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	report, err := h.ReportService.Relabel(r.Context(), userPayload.ID, reportID, relabelReportDTO)
 	if err != nil {
 		api.SendError(w, err)
 		return
@@ -1,11 +1,16 @@
 package handler
 
 import (
+	"archive/zip"
 	"bytes"
+	"encoding/csv"
 	"encoding/json"
+	"encoding/xml"
 	"fmt"
+	"image/jpeg"
 	"io"
 	"io/ioutil"
+	"math"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
@@ -13,9 +18,12 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
 	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
 	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/service"
 )
 
 func TestReportHandlerNewReport(t *testing.T) {
@@ -552,6 +560,109 @@ func TestReportHandlerUpdateReport(t *testing.T) {
 		router.ServeHTTP(res, req)
 
 		assertResponseCode(t, http.StatusOK, res.Code)
+
+		req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/reports/%d/history", createReportResponse.Data.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+userDTO.Token)
+		res = httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		resBody, _ = ioutil.ReadAll(res.Body)
+		historyResponse := struct {
+			Data []*entity.ReportStatusHistory `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &historyResponse)
+
+		if len(historyResponse.Data) != 1 {
+			t.Fatalf("Expecting status history length to be 1 but got %d instead", len(historyResponse.Data))
+		}
+
+		history := historyResponse.Data[0]
+		if history.OldStatus != "Reported" || history.NewStatus != "Under Repair" {
+			t.Errorf("Expecting status change from %q to %q but got %q to %q instead", "Reported", "Under Repair", history.OldStatus, history.NewStatus)
+		}
+
+		if history.ActorName != adminDTO.Data.User.Name {
+			t.Errorf("Expecting actor name to be %q but got %q instead", adminDTO.Data.User.Name, history.ActorName)
+		}
+
+		strangerDTO, res := register(&model.CreateUserDTO{
+			Name:        "sukirman",
+			PhoneNumber: "+6217340055640",
+			Email:       "sukirman@gmail.com",
+			Password:    "12345678",
+		})
+
+		assertResponseCode(t, http.StatusCreated, res.Code)
+
+		req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/reports/%d/history", createReportResponse.Data.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+strangerDTO.Token)
+		res = httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusForbidden, res.Code)
+
+		for token, want := range map[string]int{userDTO.Token: 1, strangerDTO.Token: 0} {
+			req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/reports/%d", createReportResponse.Data.ID), nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			res = httptest.NewRecorder()
+
+			router.ServeHTTP(res, req)
+
+			assertResponseCode(t, http.StatusOK, res.Code)
+
+			resBody, _ = ioutil.ReadAll(res.Body)
+			reportResponse := struct {
+				Data *entity.Report `json:"data"`
+			}{}
+			json.Unmarshal(resBody, &reportResponse)
+
+			if len(reportResponse.Data.StatusHistory) != want {
+				t.Errorf("Expecting status history length to be %d but got %d instead", want, len(reportResponse.Data.StatusHistory))
+			}
+		}
+	})
+
+	t.Run("update with illegal status transition", func(t *testing.T) {
+		createUserDTO := &model.CreateUserDTO{
+			Name:        "bambank",
+			PhoneNumber: "+6213246400891",
+			Email:       "qweoiuzxc@gmail.com",
+			Password:    "12345678",
+		}
+		userDTO, res := register(createUserDTO)
+
+		assertResponseCode(t, http.StatusCreated, res.Code)
+
+		res = sendReport(t, userDTO.Token, map[string]string{
+			"lat":     "-7.666369905243495",
+			"lng":     "110.66331442645793",
+			"note":    "",
+			"address": "mataram",
+		})
+		assertResponseCode(t, http.StatusCreated, res.Code)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		createReportResponse := struct {
+			Data *entity.Report `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &createReportResponse)
+
+		updateReportDTO := &model.UpdateReportDTO{
+			Status: "Completed",
+		}
+		b, _ := json.Marshal(updateReportDTO)
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/reports/%d", createReportResponse.Data.ID), bytes.NewBuffer(b))
+		req.Header.Set("Authorization", "Bearer "+adminDTO.Data.Token)
+		req.Header.Set("Content-Type", "application/json")
+		res = httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusConflict, res.Code)
 	})
 
 	t.Run("update without admin role", func(t *testing.T) {
@@ -620,3 +731,2428 @@ func TestReportHandlerUpdateReport(t *testing.T) {
 		assertResponseCode(t, http.StatusBadRequest, res.Code)
 	})
 }
+
+func TestReportHandlerGetReport(t *testing.T) {
+	createUserDTO := &model.CreateUserDTO{
+		Name:        "sukijan",
+		PhoneNumber: "+6217340014410",
+		Email:       "sukijan@gmail.com",
+		Password:    "12345678",
+	}
+	userDTO, res := register(createUserDTO)
+
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	res = sendReport(t, userDTO.Token, map[string]string{
+		"lat":     "-7.666369905243495",
+		"lng":     "110.66331442645793",
+		"note":    "lubang besar",
+		"address": "jalan sudirman",
+	})
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	resBody, _ := ioutil.ReadAll(res.Body)
+	createReportResponse := struct {
+		Data *entity.Report `json:"data"`
+	}{}
+	json.Unmarshal(resBody, &createReportResponse)
+
+	t.Run("get report normally", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/reports/%d", createReportResponse.Data.ID), nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data *entity.Report `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		if apiResponse.Data.ID != createReportResponse.Data.ID {
+			t.Errorf("Expecting report id to be %d but got %d instead", createReportResponse.Data.ID, apiResponse.Data.ID)
+		}
+
+		if apiResponse.Data.ReporterName != createUserDTO.Name {
+			t.Errorf("Expecting reporter name to be %q but got %q instead", createUserDTO.Name, apiResponse.Data.ReporterName)
+		}
+
+		if apiResponse.Data.Address != "jalan sudirman" {
+			t.Errorf("Expecting address to be %q but got %q instead", "jalan sudirman", apiResponse.Data.Address)
+		}
+	})
+
+	t.Run("get nonexistent report", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/reports/%d", 99999), nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("get report with invalid param", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/reports/%s", "yoloo"), nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusBadRequest, res.Code)
+	})
+}
+
+func TestReportHandlerGetReportsNearby(t *testing.T) {
+	createUserDTO := &model.CreateUserDTO{
+		Name:        "tukimin",
+		PhoneNumber: "+6217340099910",
+		Email:       "tukimin@gmail.com",
+		Password:    "12345678",
+	}
+	userDTO, res := register(createUserDTO)
+
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	reportFields := []map[string]string{
+		{
+			"lat":     "-6.175392",
+			"lng":     "106.827153",
+			"note":    "",
+			"address": "monas",
+		},
+		{
+			"lat":     "-6.176500",
+			"lng":     "106.827153",
+			"note":    "",
+			"address": "medan merdeka selatan",
+		},
+		{
+			"lat":     "-6.185000",
+			"lng":     "106.827153",
+			"note":    "",
+			"address": "jalan thamrin",
+		},
+	}
+
+	for _, v := range reportFields {
+		res := sendReport(t, userDTO.Token, v)
+		assertResponseCode(t, http.StatusCreated, res.Code)
+	}
+
+	t.Run("get reports near a point", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/reports?near=-6.175392,106.827153&radius=500", nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data []*entity.Report `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		if len(apiResponse.Data) != 2 {
+			t.Fatalf("Expecting the length of reports to be 2 but got %d instead", len(apiResponse.Data))
+		}
+
+		if apiResponse.Data[0].Address != "monas" {
+			t.Errorf("Expecting the nearest report to be %q but got %q instead", "monas", apiResponse.Data[0].Address)
+		}
+
+		for k, v := range apiResponse.Data {
+			if v.Distance == nil || *v.Distance > 500 {
+				t.Errorf("Expecting report distance to be within 500 meters")
+			}
+			if k > 0 && *apiResponse.Data[k-1].Distance > *v.Distance {
+				t.Error("Expecting reports to be ordered by distance ascending")
+			}
+		}
+	})
+
+	t.Run("get reports near a point with lastseenid", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/reports?near=-6.175392,106.827153&radius=5000&limit=1", nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		firstPage := struct {
+			Data []*entity.Report `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &firstPage)
+
+		if len(firstPage.Data) != 1 {
+			t.Fatalf("Expecting the length of reports to be 1 but got %d instead", len(firstPage.Data))
+		}
+
+		req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/reports?near=-6.175392,106.827153&radius=5000&limit=2&lastseenid=%d", firstPage.Data[0].ID), nil)
+		res = httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		resBody, _ = ioutil.ReadAll(res.Body)
+		secondPage := struct {
+			Data []*entity.Report `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &secondPage)
+
+		if len(secondPage.Data) != 2 {
+			t.Fatalf("Expecting the length of reports to be 2 but got %d instead", len(secondPage.Data))
+		}
+
+		if *secondPage.Data[0].Distance < *firstPage.Data[0].Distance {
+			t.Error("Expecting next page to continue after the last seen report")
+		}
+	})
+
+	t.Run("get reports inside bounding box", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/reports?bbox=106.82,-6.18,106.83,-6.17", nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data []*entity.Report `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		if len(apiResponse.Data) != 2 {
+			t.Errorf("Expecting the length of reports to be 2 but got %d instead", len(apiResponse.Data))
+		}
+	})
+
+	t.Run("get reports with invalid geo arguments", func(t *testing.T) {
+		for _, query := range []string{
+			"near=abc",
+			"near=-6.17,106.82&radius=-1",
+			"radius=100",
+			"bbox=106.83,-6.18,106.82,-6.17",
+		} {
+			req := httptest.NewRequest(http.MethodGet, "/api/reports?"+query, nil)
+			res := httptest.NewRecorder()
+
+			router.ServeHTTP(res, req)
+
+			assertResponseCode(t, http.StatusBadRequest, res.Code)
+		}
+	})
+}
+
+func TestReportHandlerGetReportsFiltered(t *testing.T) {
+	createUserDTO := &model.CreateUserDTO{
+		Name:        "sumarni",
+		PhoneNumber: "+6217340088810",
+		Email:       "sumarni@gmail.com",
+		Password:    "12345678",
+	}
+	userDTO, res := register(createUserDTO)
+
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	reportFields := []map[string]string{
+		{
+			"lat":     "-7.666369905243495",
+			"lng":     "110.66331442645793",
+			"note":    "",
+			"address": "mataram",
+		},
+		{
+			"lat":     "-7.666369905243495",
+			"lng":     "110.66331442645793",
+			"note":    "",
+			"address": "lawang sewu",
+		},
+	}
+
+	for _, v := range reportFields {
+		res := sendReport(t, userDTO.Token, v)
+		assertResponseCode(t, http.StatusCreated, res.Code)
+	}
+
+	getReports := func(t *testing.T, query string) ([]*entity.Report, *model.ReportFacets) {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/reports?"+query, nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data []*entity.Report    `json:"data"`
+			Meta *model.ReportFacets `json:"meta"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		return apiResponse.Data, apiResponse.Meta
+	}
+
+	t.Run("filter by reporter and status", func(t *testing.T) {
+		reports, facets := getReports(t, fmt.Sprintf("reporter=%d&status=Reported", userDTO.User.ID))
+
+		if len(reports) != 2 {
+			t.Errorf("Expecting the length of reports to be 2 but got %d instead", len(reports))
+		}
+
+		if facets.Total != 2 {
+			t.Errorf("Expecting total to be 2 but got %d instead", facets.Total)
+		}
+
+		if facets.Statuses["Reported"] != 2 {
+			t.Errorf("Expecting Reported facet to be 2 but got %d instead", facets.Statuses["Reported"])
+		}
+	})
+
+	t.Run("status facet ignores the status filter", func(t *testing.T) {
+		reports, facets := getReports(t, fmt.Sprintf("reporter=%d&status=Completed", userDTO.User.ID))
+
+		if len(reports) != 0 {
+			t.Errorf("Expecting the length of reports to be 0 but got %d instead", len(reports))
+		}
+
+		if facets.Statuses["Reported"] != 2 {
+			t.Errorf("Expecting Reported facet to be 2 but got %d instead", facets.Statuses["Reported"])
+		}
+	})
+
+	t.Run("filter by class", func(t *testing.T) {
+		reports, facets := getReports(t, fmt.Sprintf("reporter=%d&class=D00,D40", userDTO.User.ID))
+
+		if len(reports) != 2 {
+			t.Errorf("Expecting the length of reports to be 2 but got %d instead", len(reports))
+		}
+
+		if facets.Classes["D00"] != 2 {
+			t.Errorf("Expecting D00 facet to be 2 but got %d instead", facets.Classes["D00"])
+		}
+	})
+
+	t.Run("filter by date range", func(t *testing.T) {
+		reports, _ := getReports(t, fmt.Sprintf("reporter=%d&from=2999-01-01", userDTO.User.ID))
+
+		if len(reports) != 0 {
+			t.Errorf("Expecting the length of reports to be 0 but got %d instead", len(reports))
+		}
+	})
+
+	t.Run("filter with invalid arguments", func(t *testing.T) {
+		for _, query := range []string{
+			"status=Fixed",
+			"class=D99",
+			"from=yesterday",
+			"reporter=abc",
+		} {
+			req := httptest.NewRequest(http.MethodGet, "/api/reports?"+query, nil)
+			res := httptest.NewRecorder()
+
+			router.ServeHTTP(res, req)
+
+			assertResponseCode(t, http.StatusBadRequest, res.Code)
+		}
+	})
+}
+
+func TestReportHandlerSearchReports(t *testing.T) {
+	createUserDTO := &model.CreateUserDTO{
+		Name:        "wagiman",
+		PhoneNumber: "+6217340077710",
+		Email:       "wagiman@gmail.com",
+		Password:    "12345678",
+	}
+	userDTO, res := register(createUserDTO)
+
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	reportFields := []map[string]string{
+		{
+			"lat":     "-7.666369905243495",
+			"lng":     "110.66331442645793",
+			"note":    "lubang di dekat pasar",
+			"address": "Jalan Sudirman No. 12",
+		},
+		{
+			"lat":     "-7.666369905243495",
+			"lng":     "110.66331442645793",
+			"note":    "retak memanjang",
+			"address": "Jalan Diponegoro",
+		},
+	}
+
+	for _, v := range reportFields {
+		res := sendReport(t, userDTO.Token, v)
+		assertResponseCode(t, http.StatusCreated, res.Code)
+	}
+
+	search := func(t *testing.T, q string) []*entity.Report {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/reports?reporter=%d&q=%s", userDTO.User.ID, q), nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data []*entity.Report `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		return apiResponse.Data
+	}
+
+	t.Run("search by note", func(t *testing.T) {
+		reports := search(t, "pasar")
+
+		if len(reports) != 1 {
+			t.Fatalf("Expecting the length of reports to be 1 but got %d instead", len(reports))
+		}
+
+		if reports[0].Relevance == nil {
+			t.Error("Expecting relevance to be set")
+		}
+	})
+
+	t.Run("search misspelled street name", func(t *testing.T) {
+		reports := search(t, "sudirmn")
+
+		if len(reports) != 1 {
+			t.Fatalf("Expecting the length of reports to be 1 but got %d instead", len(reports))
+		}
+
+		if reports[0].Address != "Jalan Sudirman No. 12" {
+			t.Errorf("Expecting address to be %q but got %q instead", "Jalan Sudirman No. 12", reports[0].Address)
+		}
+	})
+
+	t.Run("search ranks by relevance", func(t *testing.T) {
+		reports := search(t, "jalan+sudirman")
+
+		if len(reports) == 0 {
+			t.Fatal("Expecting reports to not empty")
+		}
+
+		if reports[0].Address != "Jalan Sudirman No. 12" {
+			t.Errorf("Expecting most relevant address to be %q but got %q instead", "Jalan Sudirman No. 12", reports[0].Address)
+		}
+
+		for k := 1; k < len(reports); k++ {
+			if *reports[k-1].Relevance < *reports[k].Relevance {
+				t.Error("Expecting reports to be ordered by relevance descending")
+			}
+		}
+	})
+}
+
+func TestReportHandlerGetReportStats(t *testing.T) {
+	adminDTO := loginAdmin(t)
+
+	createUserDTO := &model.CreateUserDTO{
+		Name:        "paimin",
+		PhoneNumber: "+6217340066610",
+		Email:       "paimin@gmail.com",
+		Password:    "12345678",
+	}
+	userDTO, res := register(createUserDTO)
+
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	for i := 0; i < 3; i++ {
+		res := sendReport(t, userDTO.Token, map[string]string{
+			"lat":     "-7.666369905243495",
+			"lng":     "110.66331442645793",
+			"note":    "",
+			"address": "mataram",
+		})
+		assertResponseCode(t, http.StatusCreated, res.Code)
+	}
+
+	t.Run("get stats normally", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/reports/stats?reporter=%d&interval=week", userDTO.User.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+adminDTO.Token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data *model.ReportStats `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		stats := apiResponse.Data
+		if stats.Total != 3 {
+			t.Errorf("Expecting total to be 3 but got %d instead", stats.Total)
+		}
+
+		if stats.Statuses["Reported"] != 3 {
+			t.Errorf("Expecting Reported count to be 3 but got %d instead", stats.Statuses["Reported"])
+		}
+
+		if stats.Classes["D00"] != 3 {
+			t.Errorf("Expecting D00 count to be 3 but got %d instead", stats.Classes["D00"])
+		}
+
+		if len(stats.Series) != 1 || stats.Series[0].Total != 3 {
+			t.Errorf("Expecting a single weekly bucket with 3 reports")
+		}
+	})
+
+	t.Run("get stats with invalid interval", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/reports/stats?interval=decade", nil)
+		req.Header.Set("Authorization", "Bearer "+adminDTO.Token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("get stats without admin role", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/reports/stats", nil)
+		req.Header.Set("Authorization", "Bearer "+userDTO.Token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusForbidden, res.Code)
+	})
+}
+
+func TestReportHandlerGetReportClusters(t *testing.T) {
+	createUserDTO := &model.CreateUserDTO{
+		Name:        "suparjo",
+		PhoneNumber: "+6217340055510",
+		Email:       "suparjo@gmail.com",
+		Password:    "12345678",
+	}
+	userDTO, res := register(createUserDTO)
+
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	reportFields := []map[string]string{
+		{
+			"lat":     "-7.257500",
+			"lng":     "112.752100",
+			"note":    "",
+			"address": "tunjungan",
+		},
+		{
+			"lat":     "-7.257600",
+			"lng":     "112.752200",
+			"note":    "",
+			"address": "tunjungan plaza",
+		},
+	}
+
+	for _, v := range reportFields {
+		res := sendReport(t, userDTO.Token, v)
+		assertResponseCode(t, http.StatusCreated, res.Code)
+	}
+
+	getClusters := func(t *testing.T, zoom int) *model.ReportClusters {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/reports/clusters?bbox=112.70,-7.30,112.80,-7.20&zoom=%d", zoom), nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data *model.ReportClusters `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		return apiResponse.Data
+	}
+
+	t.Run("get clusters when zoomed out", func(t *testing.T) {
+		clusters := getClusters(t, 10)
+
+		if len(clusters.Clusters) != 1 {
+			t.Fatalf("Expecting the length of clusters to be 1 but got %d instead", len(clusters.Clusters))
+		}
+
+		if clusters.Clusters[0].Count != 2 {
+			t.Errorf("Expecting cluster count to be 2 but got %d instead", clusters.Clusters[0].Count)
+		}
+
+		if clusters.Clusters[0].DominantClass == "" {
+			t.Error("Expecting dominant class to be not empty")
+		}
+
+		if len(clusters.Reports) != 0 {
+			t.Errorf("Expecting no individual reports but got %d instead", len(clusters.Reports))
+		}
+	})
+
+	t.Run("get individual reports when zoomed in", func(t *testing.T) {
+		clusters := getClusters(t, 18)
+
+		if len(clusters.Reports) != 2 {
+			t.Errorf("Expecting the length of reports to be 2 but got %d instead", len(clusters.Reports))
+		}
+	})
+
+	t.Run("get clusters without bbox", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/reports/clusters?zoom=10", nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusBadRequest, res.Code)
+	})
+}
+
+func TestReportHandlerGetReportsGeoJSON(t *testing.T) {
+	createUserDTO := &model.CreateUserDTO{
+		Name:        "ngatiyem",
+		PhoneNumber: "+6217340044410",
+		Email:       "ngatiyem@gmail.com",
+		Password:    "12345678",
+	}
+	userDTO, res := register(createUserDTO)
+
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	res = sendReport(t, userDTO.Token, map[string]string{
+		"lat":     "-7.666369905243495",
+		"lng":     "110.66331442645793",
+		"note":    "",
+		"address": "mataram",
+	})
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	assertFeatureCollection := func(t *testing.T, res *httptest.ResponseRecorder) {
+		t.Helper()
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		if contentType := res.Header().Get("Content-Type"); contentType != "application/geo+json" {
+			t.Errorf("Expecting content type to be %q but got %q instead", "application/geo+json", contentType)
+		}
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		featureCollection := new(api.FeatureCollection)
+		json.Unmarshal(resBody, featureCollection)
+
+		if featureCollection.Type != "FeatureCollection" {
+			t.Errorf("Expecting type to be %q but got %q instead", "FeatureCollection", featureCollection.Type)
+		}
+
+		if len(featureCollection.Features) != 1 {
+			t.Fatalf("Expecting the length of features to be 1 but got %d instead", len(featureCollection.Features))
+		}
+
+		feature := featureCollection.Features[0]
+		if math.Abs(feature.Geometry.Coordinates[0]-110.6633) > 1e-3 || math.Abs(feature.Geometry.Coordinates[1]+7.6663) > 1e-3 {
+			t.Errorf("Expecting coordinates to be in lng, lat order but got %v instead", feature.Geometry.Coordinates)
+		}
+
+		if feature.Properties["status"] != "Reported" {
+			t.Errorf("Expecting status property to be %q but got %v instead", "Reported", feature.Properties["status"])
+		}
+	}
+
+	t.Run("get reports with format parameter", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/reports?reporter=%d&format=geojson", userDTO.User.ID), nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertFeatureCollection(t, res)
+	})
+
+	t.Run("get reports with accept header", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/reports?reporter=%d", userDTO.User.ID), nil)
+		req.Header.Set("Accept", "application/geo+json")
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertFeatureCollection(t, res)
+	})
+}
+
+func TestReportHandlerExportReports(t *testing.T) {
+	adminDTO := loginAdmin(t)
+
+	createUserDTO := &model.CreateUserDTO{
+		Name:        "karyono",
+		PhoneNumber: "+6217340022210",
+		Email:       "karyono@gmail.com",
+		Password:    "12345678",
+	}
+	userDTO, res := register(createUserDTO)
+
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	for _, address := range []string{"mataram", "lawang sewu"} {
+		res := sendReport(t, userDTO.Token, map[string]string{
+			"lat":     "-7.666369905243495",
+			"lng":     "110.66331442645793",
+			"note":    "",
+			"address": address,
+		})
+		assertResponseCode(t, http.StatusCreated, res.Code)
+	}
+
+	t.Run("export reports as csv", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/reports/export?reporter=%d", userDTO.User.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+adminDTO.Token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		records, err := csv.NewReader(res.Body).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(records) != 3 {
+			t.Fatalf("Expecting 3 rows including the header but got %d instead", len(records))
+		}
+
+		if records[1][1] != createUserDTO.Name {
+			t.Errorf("Expecting reporter name to be %q but got %q instead", createUserDTO.Name, records[1][1])
+		}
+	})
+
+	t.Run("export reports as xlsx", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/reports/export?reporter=%d&format=xlsx", userDTO.User.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+adminDTO.Token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		body := res.Body.Bytes()
+		if _, err := zip.NewReader(bytes.NewReader(body), int64(len(body))); err != nil {
+			t.Errorf("Expecting a valid xlsx archive, but got %v instead", err)
+		}
+	})
+
+	t.Run("export reports with invalid format", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/reports/export?format=pdf", nil)
+		req.Header.Set("Authorization", "Bearer "+adminDTO.Token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("export reports without admin role", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/reports/export", nil)
+		req.Header.Set("Authorization", "Bearer "+userDTO.Token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusForbidden, res.Code)
+	})
+}
+
+func TestReportHandlerExportReportsKML(t *testing.T) {
+	createUserDTO := &model.CreateUserDTO{
+		Name:        "surtini",
+		PhoneNumber: "+6217340011110",
+		Email:       "surtini@gmail.com",
+		Password:    "12345678",
+	}
+	userDTO, res := register(createUserDTO)
+
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	res = sendReport(t, userDTO.Token, map[string]string{
+		"lat":     "-7.666369905243495",
+		"lng":     "110.66331442645793",
+		"note":    "",
+		"address": "mataram",
+	})
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/reports/export.kml?reporter=%d", userDTO.User.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+userDTO.Token)
+	res = httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	assertResponseCode(t, http.StatusForbidden, res.Code)
+
+	adminDTO := loginAdmin(t)
+	req = httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/reports/export.kml?reporter=%d", userDTO.User.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+adminDTO.Token)
+	res = httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+
+	assertResponseCode(t, http.StatusOK, res.Code)
+
+	doc := struct {
+		Document struct {
+			Placemarks []struct {
+				StyleURL    string `xml:"styleUrl"`
+				Description string `xml:"description"`
+			} `xml:"Placemark"`
+		}
+	}{}
+	if err := xml.Unmarshal(res.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("Expecting a valid KML document, but got %v instead", err)
+	}
+
+	if len(doc.Document.Placemarks) != 1 {
+		t.Fatalf("Expecting 1 placemark but got %d instead", len(doc.Document.Placemarks))
+	}
+
+	placemark := doc.Document.Placemarks[0]
+	if placemark.StyleURL != "#reported-d00" {
+		t.Errorf("Expecting style url to be %q but got %q instead", "#reported-d00", placemark.StyleURL)
+	}
+
+	if !strings.Contains(placemark.Description, "https://storage.googleapis.com/test/predict.jpg") {
+		t.Error("Expecting description to contain the image url")
+	}
+}
+
+func TestReportHandlerDuplicateReports(t *testing.T) {
+	reportService := reportSRV.(*service.ReportServiceImpl)
+	reportService.DuplicateRadius = 25
+	defer func() {
+		reportService.DuplicateRadius = 0
+	}()
+
+	createUserDTO := &model.CreateUserDTO{
+		Name:        "sukardi",
+		PhoneNumber: "+6217340055530",
+		Email:       "sukardi@gmail.com",
+		Password:    "12345678",
+	}
+	userDTO, res := register(createUserDTO)
+
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	reportFields := []map[string]string{
+		{
+			"lat":     "-0.502106",
+			"lng":     "117.153709",
+			"note":    "",
+			"address": "jalan juanda",
+		},
+		{
+			"lat":     "-0.502150",
+			"lng":     "117.153750",
+			"note":    "",
+			"address": "jalan juanda samping",
+		},
+	}
+
+	reports := make([]*entity.Report, len(reportFields))
+	for k, v := range reportFields {
+		res := sendReport(t, userDTO.Token, v)
+		assertResponseCode(t, http.StatusCreated, res.Code)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data *entity.Report `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+		reports[k] = apiResponse.Data
+	}
+	canonical, duplicate := reports[0], reports[1]
+
+	getNearby := func(t *testing.T, query string) []*entity.Report {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/reports?near=-0.502106,117.153709&radius=100"+query, nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data []*entity.Report `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		return apiResponse.Data
+	}
+
+	sendAdmin := func(t *testing.T, path string, body interface{}) *httptest.ResponseRecorder {
+		t.Helper()
+
+		adminDTO := loginAdmin(t)
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(b))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+adminDTO.Token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		return res
+	}
+
+	t.Run("link nearby report with overlapping classes", func(t *testing.T) {
+		if canonical.CanonicalID != nil {
+			t.Errorf("Expecting the first report to be canonical but got canonical id %d", *canonical.CanonicalID)
+		}
+
+		if duplicate.CanonicalID == nil || *duplicate.CanonicalID != canonical.ID {
+			t.Fatalf("Expecting the second report to be a duplicate of %d", canonical.ID)
+		}
+
+		reports := getNearby(t, "")
+		if len(reports) != 1 {
+			t.Fatalf("Expecting the length of reports to be 1 but got %d instead", len(reports))
+		}
+
+		if reports[0].DuplicateCount != 1 {
+			t.Errorf("Expecting duplicate count to be 1 but got %d instead", reports[0].DuplicateCount)
+		}
+
+		if len(getNearby(t, "&includeDuplicates=true")) != 2 {
+			t.Error("Expecting duplicates to be listed with includeDuplicates")
+		}
+	})
+
+	t.Run("split duplicate as non admin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/api/reports/%d/split", duplicate.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+userDTO.Token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusForbidden, res.Code)
+	})
+
+	t.Run("split duplicate", func(t *testing.T) {
+		res := sendAdmin(t, fmt.Sprintf("/api/reports/%d/split", duplicate.ID), nil)
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		if len(getNearby(t, "")) != 2 {
+			t.Error("Expecting the split report to be listed")
+		}
+	})
+
+	t.Run("split canonical report", func(t *testing.T) {
+		res := sendAdmin(t, fmt.Sprintf("/api/reports/%d/split", canonical.ID), nil)
+		assertResponseCode(t, http.StatusConflict, res.Code)
+	})
+
+	t.Run("merge report into itself", func(t *testing.T) {
+		res := sendAdmin(t, fmt.Sprintf("/api/reports/%d/merge", canonical.ID), &model.MergeReportDTO{
+			CanonicalID: canonical.ID,
+		})
+		assertResponseCode(t, http.StatusConflict, res.Code)
+	})
+
+	t.Run("merge report", func(t *testing.T) {
+		res := sendAdmin(t, fmt.Sprintf("/api/reports/%d/merge", duplicate.ID), &model.MergeReportDTO{
+			CanonicalID: canonical.ID,
+		})
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data *entity.Report `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		if apiResponse.Data.CanonicalID == nil || *apiResponse.Data.CanonicalID != canonical.ID {
+			t.Errorf("Expecting the report to be merged into %d", canonical.ID)
+		}
+	})
+
+	t.Run("merge into duplicate follows its canonical report", func(t *testing.T) {
+		res := sendAdmin(t, fmt.Sprintf("/api/reports/%d/merge", canonical.ID), &model.MergeReportDTO{
+			CanonicalID: duplicate.ID,
+		})
+		assertResponseCode(t, http.StatusConflict, res.Code)
+	})
+
+	t.Run("merge into non existing report", func(t *testing.T) {
+		res := sendAdmin(t, fmt.Sprintf("/api/reports/%d/merge", duplicate.ID), &model.MergeReportDTO{
+			CanonicalID: 999999,
+		})
+		assertResponseCode(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("merge into rejected report", func(t *testing.T) {
+		res := sendReport(t, userDTO.Token, map[string]string{
+			"lat":     "-0.512106",
+			"lng":     "117.153709",
+			"note":    "",
+			"address": "jalan juanda ujung",
+		})
+		assertResponseCode(t, http.StatusCreated, res.Code)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data *entity.Report `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+		rejected := apiResponse.Data
+
+		b, _ := json.Marshal(&model.UpdateReportDTO{
+			Status: "Rejected",
+		})
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/reports/%d", rejected.ID), bytes.NewBuffer(b))
+		req.Header.Set("Authorization", "Bearer "+loginAdmin(t).Token)
+		req.Header.Set("Content-Type", "application/json")
+		res = httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		res = sendAdmin(t, fmt.Sprintf("/api/reports/%d/merge", duplicate.ID), &model.MergeReportDTO{
+			CanonicalID: rejected.ID,
+		})
+		assertResponseCode(t, http.StatusConflict, res.Code)
+	})
+}
+
+func TestReportHandlerConfirmReport(t *testing.T) {
+	createUserDTOs := []*model.CreateUserDTO{
+		{
+			Name:        "sutini",
+			PhoneNumber: "+6217340055540",
+			Email:       "sutini@gmail.com",
+			Password:    "12345678",
+		},
+		{
+			Name:        "sutarno",
+			PhoneNumber: "+6217340055541",
+			Email:       "sutarno@gmail.com",
+			Password:    "12345678",
+		},
+	}
+	users := make([]*model.UserDTO, len(createUserDTOs))
+	for k, v := range createUserDTOs {
+		userDTO, res := register(v)
+		assertResponseCode(t, http.StatusCreated, res.Code)
+		users[k] = userDTO
+	}
+	reporter, passerby := users[0], users[1]
+
+	reportFields := []map[string]string{
+		{
+			"lat":     "-3.316694",
+			"lng":     "114.590111",
+			"note":    "",
+			"address": "jalan lambung mangkurat",
+		},
+		{
+			"lat":     "-3.326694",
+			"lng":     "114.590111",
+			"note":    "",
+			"address": "jalan ahmad yani",
+		},
+	}
+
+	reports := make([]*entity.Report, len(reportFields))
+	for k, v := range reportFields {
+		res := sendReport(t, reporter.Token, v)
+		assertResponseCode(t, http.StatusCreated, res.Code)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data *entity.Report `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+		reports[k] = apiResponse.Data
+	}
+	confirmed := reports[1]
+
+	sendConfirm := func(t *testing.T, method, token string, reportID int) *httptest.ResponseRecorder {
+		t.Helper()
+
+		req := httptest.NewRequest(method, fmt.Sprintf("/api/reports/%d/confirm", reportID), nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		return res
+	}
+
+	confirmationCount := func(t *testing.T, res *httptest.ResponseRecorder) int {
+		t.Helper()
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data *entity.Report `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		return apiResponse.Data.ConfirmationCount
+	}
+
+	t.Run("confirm without token", func(t *testing.T) {
+		res := sendConfirm(t, http.MethodPost, "", confirmed.ID)
+		assertResponseCode(t, http.StatusUnauthorized, res.Code)
+	})
+
+	t.Run("confirm own report", func(t *testing.T) {
+		res := sendConfirm(t, http.MethodPost, reporter.Token, confirmed.ID)
+		assertResponseCode(t, http.StatusConflict, res.Code)
+	})
+
+	t.Run("confirm report", func(t *testing.T) {
+		res := sendConfirm(t, http.MethodPost, passerby.Token, confirmed.ID)
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		if got := confirmationCount(t, res); got != 1 {
+			t.Errorf("Expecting confirmation count to be 1 but got %d instead", got)
+		}
+	})
+
+	t.Run("confirm report twice", func(t *testing.T) {
+		res := sendConfirm(t, http.MethodPost, passerby.Token, confirmed.ID)
+		assertResponseCode(t, http.StatusConflict, res.Code)
+	})
+
+	t.Run("sort by confirmations", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/reports?near=-3.316694,114.590111&radius=5000&sort=confirmations", nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data []*entity.Report `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		if len(apiResponse.Data) != 2 {
+			t.Fatalf("Expecting the length of reports to be 2 but got %d instead", len(apiResponse.Data))
+		}
+
+		if apiResponse.Data[0].ID != confirmed.ID {
+			t.Errorf("Expecting the confirmed report to be first but got report %d instead", apiResponse.Data[0].ID)
+		}
+	})
+
+	t.Run("sort by invalid key", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/reports?sort=name", nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("unconfirm report", func(t *testing.T) {
+		res := sendConfirm(t, http.MethodDelete, passerby.Token, confirmed.ID)
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		if got := confirmationCount(t, res); got != 0 {
+			t.Errorf("Expecting confirmation count to be 0 but got %d instead", got)
+		}
+	})
+
+	t.Run("unconfirm report twice", func(t *testing.T) {
+		res := sendConfirm(t, http.MethodDelete, passerby.Token, confirmed.ID)
+		assertResponseCode(t, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestReportCommentHandler(t *testing.T) {
+	createUserDTOs := []*model.CreateUserDTO{
+		{
+			Name:        "sumiyati",
+			PhoneNumber: "+6217340055550",
+			Email:       "sumiyati@gmail.com",
+			Password:    "12345678",
+		},
+		{
+			Name:        "sumarno",
+			PhoneNumber: "+6217340055551",
+			Email:       "sumarno@gmail.com",
+			Password:    "12345678",
+		},
+	}
+	users := make([]*model.UserDTO, len(createUserDTOs))
+	for k, v := range createUserDTOs {
+		userDTO, res := register(v)
+		assertResponseCode(t, http.StatusCreated, res.Code)
+		users[k] = userDTO
+	}
+	reporter, stranger := users[0], users[1]
+	adminDTO := loginAdmin(t)
+
+	res := sendReport(t, reporter.Token, map[string]string{
+		"lat":     "-7.666369905243495",
+		"lng":     "110.66331442645793",
+		"note":    "",
+		"address": "jalan pemuda",
+	})
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	resBody, _ := ioutil.ReadAll(res.Body)
+	reportResponse := struct {
+		Data *entity.Report `json:"data"`
+	}{}
+	json.Unmarshal(resBody, &reportResponse)
+	path := fmt.Sprintf("/api/reports/%d/comments", reportResponse.Data.ID)
+
+	sendComment := func(t *testing.T, token string, dto *model.CreateReportCommentDTO) *httptest.ResponseRecorder {
+		t.Helper()
+
+		b, _ := json.Marshal(dto)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewBuffer(b))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		return res
+	}
+
+	getComments := func(t *testing.T, token string) []*entity.ReportComment {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data []*entity.ReportComment `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		return apiResponse.Data
+	}
+
+	t.Run("admin asks a follow up question", func(t *testing.T) {
+		res := sendComment(t, adminDTO.Token, &model.CreateReportCommentDTO{
+			Body: "Which lane is it in?",
+		})
+		assertResponseCode(t, http.StatusCreated, res.Code)
+	})
+
+	t.Run("admin writes an internal comment", func(t *testing.T) {
+		res := sendComment(t, adminDTO.Token, &model.CreateReportCommentDTO{
+			Body:     "Schedule with the next patching crew",
+			Internal: true,
+		})
+		assertResponseCode(t, http.StatusCreated, res.Code)
+	})
+
+	t.Run("reporter answers", func(t *testing.T) {
+		res := sendComment(t, reporter.Token, &model.CreateReportCommentDTO{
+			Body: "The left lane, heading north",
+		})
+		assertResponseCode(t, http.StatusCreated, res.Code)
+	})
+
+	t.Run("reporter writes an internal comment", func(t *testing.T) {
+		res := sendComment(t, reporter.Token, &model.CreateReportCommentDTO{
+			Body:     "psst",
+			Internal: true,
+		})
+		assertResponseCode(t, http.StatusForbidden, res.Code)
+	})
+
+	t.Run("stranger comments", func(t *testing.T) {
+		res := sendComment(t, stranger.Token, &model.CreateReportCommentDTO{
+			Body: "me too",
+		})
+		assertResponseCode(t, http.StatusForbidden, res.Code)
+	})
+
+	t.Run("comment without body", func(t *testing.T) {
+		res := sendComment(t, reporter.Token, &model.CreateReportCommentDTO{})
+		assertResponseCode(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("admin sees internal comments", func(t *testing.T) {
+		comments := getComments(t, adminDTO.Token)
+		if len(comments) != 3 {
+			t.Errorf("Expecting the length of comments to be 3 but got %d instead", len(comments))
+		}
+	})
+
+	t.Run("reporter does not see internal comments", func(t *testing.T) {
+		comments := getComments(t, reporter.Token)
+		if len(comments) != 2 {
+			t.Fatalf("Expecting the length of comments to be 2 but got %d instead", len(comments))
+		}
+
+		for _, v := range comments {
+			if v.Internal {
+				t.Errorf("Expecting comment %d to be public", v.ID)
+			}
+		}
+
+		if comments[1].AuthorName != createUserDTOs[0].Name {
+			t.Errorf("Expecting author name to be %s but got %s instead", createUserDTOs[0].Name, comments[1].AuthorName)
+		}
+	})
+
+	t.Run("comments of non existing report", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/reports/999999/comments", nil)
+		req.Header.Set("Authorization", "Bearer "+reporter.Token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusNotFound, res.Code)
+	})
+}
+
+func TestReportHandlerEditAndWithdrawReport(t *testing.T) {
+	createUserDTOs := []*model.CreateUserDTO{
+		{
+			Name:        "suwarni",
+			PhoneNumber: "+6217340055560",
+			Email:       "suwarni@gmail.com",
+			Password:    "12345678",
+		},
+		{
+			Name:        "suwarno",
+			PhoneNumber: "+6217340055561",
+			Email:       "suwarno@gmail.com",
+			Password:    "12345678",
+		},
+	}
+	users := make([]*model.UserDTO, len(createUserDTOs))
+	for k, v := range createUserDTOs {
+		userDTO, res := register(v)
+		assertResponseCode(t, http.StatusCreated, res.Code)
+		users[k] = userDTO
+	}
+	reporter, stranger := users[0], users[1]
+	adminDTO := loginAdmin(t)
+
+	res := sendReport(t, reporter.Token, map[string]string{
+		"lat":     "-8.650000",
+		"lng":     "115.216667",
+		"note":    "",
+		"address": "jalan gajah mada",
+	})
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	resBody, _ := ioutil.ReadAll(res.Body)
+	reportResponse := struct {
+		Data *entity.Report `json:"data"`
+	}{}
+	json.Unmarshal(resBody, &reportResponse)
+	reportID := reportResponse.Data.ID
+
+	send := func(t *testing.T, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+		t.Helper()
+
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewBuffer(b))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		return res
+	}
+
+	decodeReport := func(t *testing.T, res *httptest.ResponseRecorder) *entity.Report {
+		t.Helper()
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data *entity.Report `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		return apiResponse.Data
+	}
+
+	listed := func(t *testing.T, path, token string) bool {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data []*entity.Report `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		for _, v := range apiResponse.Data {
+			if v.ID == reportID {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	reportPath := fmt.Sprintf("/api/reports/%d", reportID)
+	address := "jalan gajah mada no. 10"
+
+	t.Run("edit someone else's report", func(t *testing.T) {
+		res := send(t, http.MethodPatch, reportPath, stranger.Token, &model.EditReportDTO{
+			Address: &address,
+		})
+		assertResponseCode(t, http.StatusForbidden, res.Code)
+	})
+
+	t.Run("edit with invalid location", func(t *testing.T) {
+		lat, lng := 100.0, 115.0
+		res := send(t, http.MethodPatch, reportPath, reporter.Token, &model.EditReportDTO{
+			Location: &model.LocationDTO{Lat: &lat, Lng: &lng},
+		})
+		assertResponseCode(t, http.StatusBadRequest, res.Code)
+
+		res = send(t, http.MethodPatch, reportPath, reporter.Token, map[string]interface{}{
+			"location": map[string]interface{}{},
+		})
+		assertResponseCode(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("edit own report", func(t *testing.T) {
+		lat, lng := -8.651, 115.217
+		res := send(t, http.MethodPatch, reportPath, reporter.Token, &model.EditReportDTO{
+			Address:  &address,
+			Location: &model.LocationDTO{Lat: &lat, Lng: &lng},
+		})
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		report := decodeReport(t, res)
+		if report.Address != address {
+			t.Errorf("Expecting address to be %s but got %s instead", address, report.Address)
+		}
+
+		if math.Abs(report.Location.Lat-(-8.651)) > 1e-6 {
+			t.Errorf("Expecting latitude to be -8.651 but got %f instead", report.Location.Lat)
+		}
+	})
+
+	t.Run("withdraw someone else's report", func(t *testing.T) {
+		res := send(t, http.MethodDelete, reportPath, stranger.Token, nil)
+		assertResponseCode(t, http.StatusForbidden, res.Code)
+	})
+
+	t.Run("withdraw own report", func(t *testing.T) {
+		res := send(t, http.MethodDelete, reportPath, reporter.Token, nil)
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		if decodeReport(t, res).DeletedAt == nil {
+			t.Error("Expecting deletedAt to be set")
+		}
+
+		if listed(t, "/api/reports?near=-8.651,115.217", reporter.Token) {
+			t.Error("Expecting withdrawn report to be hidden from reports")
+		}
+
+		if listed(t, "/api/reports/history", reporter.Token) {
+			t.Error("Expecting withdrawn report to be hidden from history")
+		}
+	})
+
+	t.Run("edit withdrawn report", func(t *testing.T) {
+		res := send(t, http.MethodPatch, reportPath, reporter.Token, &model.EditReportDTO{
+			Address: &address,
+		})
+		assertResponseCode(t, http.StatusConflict, res.Code)
+	})
+
+	t.Run("get withdrawn report", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, reportPath, nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusNotFound, res.Code)
+
+		res = send(t, http.MethodGet, reportPath, stranger.Token, nil)
+		assertResponseCode(t, http.StatusNotFound, res.Code)
+
+		res = send(t, http.MethodPost, reportPath+"/confirm", stranger.Token, nil)
+		assertResponseCode(t, http.StatusNotFound, res.Code)
+
+		res = send(t, http.MethodGet, reportPath+"/comments", stranger.Token, nil)
+		assertResponseCode(t, http.StatusNotFound, res.Code)
+
+		res = send(t, http.MethodGet, reportPath, reporter.Token, nil)
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		res = send(t, http.MethodGet, reportPath, adminDTO.Token, nil)
+		assertResponseCode(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("list withdrawn reports as non admin", func(t *testing.T) {
+		res := send(t, http.MethodGet, "/api/reports/withdrawn", reporter.Token, nil)
+		assertResponseCode(t, http.StatusForbidden, res.Code)
+	})
+
+	t.Run("list withdrawn reports", func(t *testing.T) {
+		if !listed(t, "/api/reports/withdrawn", adminDTO.Token) {
+			t.Error("Expecting withdrawn report to be listed")
+		}
+	})
+
+	t.Run("restore report", func(t *testing.T) {
+		res := send(t, http.MethodPost, reportPath+"/restore", adminDTO.Token, nil)
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		if decodeReport(t, res).DeletedAt != nil {
+			t.Error("Expecting deletedAt to be cleared")
+		}
+
+		if !listed(t, "/api/reports/history", reporter.Token) {
+			t.Error("Expecting restored report to be listed in history")
+		}
+	})
+
+	t.Run("restore active report", func(t *testing.T) {
+		res := send(t, http.MethodPost, reportPath+"/restore", adminDTO.Token, nil)
+		assertResponseCode(t, http.StatusConflict, res.Code)
+	})
+
+	t.Run("edit report under repair", func(t *testing.T) {
+		res := send(t, http.MethodPut, reportPath, adminDTO.Token, &model.UpdateReportDTO{
+			Status: "Under Repair",
+		})
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		res = send(t, http.MethodPatch, reportPath, reporter.Token, &model.EditReportDTO{
+			Address: &address,
+		})
+		assertResponseCode(t, http.StatusConflict, res.Code)
+	})
+}
+
+func TestReportHandlerReportScores(t *testing.T) {
+	createUserDTO := &model.CreateUserDTO{
+		Name:        "sugiyem",
+		PhoneNumber: "+6217340055570",
+		Email:       "sugiyem@gmail.com",
+		Password:    "12345678",
+	}
+	userDTO, res := register(createUserDTO)
+
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	res = sendReport(t, userDTO.Token, map[string]string{
+		"lat":     "-5.147665",
+		"lng":     "119.432732",
+		"note":    "",
+		"address": "jalan penghibur",
+	})
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	resBody, _ := ioutil.ReadAll(res.Body)
+	reportResponse := struct {
+		Data *entity.Report `json:"data"`
+	}{}
+	json.Unmarshal(resBody, &reportResponse)
+	reportID := reportResponse.Data.ID
+
+	getReports := func(t *testing.T, query string) ([]*entity.Report, int) {
+		t.Helper()
+
+		req := httptest.NewRequest(http.MethodGet, "/api/reports?near=-5.147665,119.432732"+query, nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data []*entity.Report `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		return apiResponse.Data, res.Code
+	}
+
+	t.Run("get report scores", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/reports/%d", reportID), nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data *entity.Report `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		if apiResponse.Data.Score == nil || *apiResponse.Data.Score != 90 {
+			t.Errorf("Expecting score to be 90 but got %v instead", apiResponse.Data.Score)
+		}
+
+		if len(apiResponse.Data.ClassScores) != 9 {
+			t.Fatalf("Expecting the length of class scores to be 9 but got %d instead", len(apiResponse.Data.ClassScores))
+		}
+
+		if apiResponse.Data.ClassScores["D01"] != 89 {
+			t.Errorf("Expecting D01 score to be 89 but got %f instead", apiResponse.Data.ClassScores["D01"])
+		}
+	})
+
+	t.Run("filter by score", func(t *testing.T) {
+		reports, code := getReports(t, "&minScore=80&maxScore=95")
+		assertResponseCode(t, http.StatusOK, code)
+
+		if len(reports) != 1 {
+			t.Errorf("Expecting the length of reports to be 1 but got %d instead", len(reports))
+		}
+
+		reports, code = getReports(t, "&minScore=95")
+		assertResponseCode(t, http.StatusOK, code)
+
+		if len(reports) != 0 {
+			t.Errorf("Expecting the length of reports to be 0 but got %d instead", len(reports))
+		}
+	})
+
+	t.Run("sort by score", func(t *testing.T) {
+		reports, code := getReports(t, "&sort=score&order=asc")
+		assertResponseCode(t, http.StatusOK, code)
+
+		if len(reports) != 1 {
+			t.Errorf("Expecting the length of reports to be 1 but got %d instead", len(reports))
+		}
+	})
+
+	t.Run("invalid score arguments", func(t *testing.T) {
+		for _, query := range []string{"&minScore=high", "&minScore=-1", "&sort=score&order=up", "&sort=score&lastseenid=2147483647"} {
+			_, code := getReports(t, query)
+			assertResponseCode(t, http.StatusBadRequest, code)
+		}
+	})
+}
+
+func TestReportHandlerReportDetections(t *testing.T) {
+	createUserDTO := &model.CreateUserDTO{
+		Name:        "sugeng",
+		PhoneNumber: "+6217340055580",
+		Email:       "sugeng@gmail.com",
+		Password:    "12345678",
+	}
+	userDTO, res := register(createUserDTO)
+
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	res = sendReport(t, userDTO.Token, map[string]string{
+		"lat":     "-6.914744",
+		"lng":     "107.609810",
+		"note":    "",
+		"address": "jalan asia afrika",
+	})
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	resBody, _ := ioutil.ReadAll(res.Body)
+	createReportResponse := struct {
+		Data *entity.Report `json:"data"`
+	}{}
+	json.Unmarshal(resBody, &createReportResponse)
+
+	t.Run("create report with detections", func(t *testing.T) {
+		if len(createReportResponse.Data.Detections) != 2 {
+			t.Errorf("Expecting the length of detections to be 2 but got %d instead", len(createReportResponse.Data.Detections))
+		}
+	})
+
+	t.Run("get report detections", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/reports/%d", createReportResponse.Data.ID), nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data *entity.Report `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		detections := apiResponse.Data.Detections
+		if len(detections) != 2 {
+			t.Fatalf("Expecting the length of detections to be 2 but got %d instead", len(detections))
+		}
+
+		if detections[0].Class != "D40" {
+			t.Errorf("Expecting the most confident detection to be D40 but got %s instead", detections[0].Class)
+		}
+
+		want := entity.Box{XMin: 10, YMin: 20, XMax: 110, YMax: 80}
+		if detections[0].Box == nil || *detections[0].Box != want {
+			t.Errorf("Expecting box to be %+v but got %+v instead", want, detections[0].Box)
+		}
+	})
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestReportHandlerGetAnnotatedImage(t *testing.T) {
+	reportService := reportSRV.(*service.ReportServiceImpl)
+	imageFetcher := reportService.ImageFetcher
+	reportService.ImageFetcher = service.NewHTTPImageFetcher(&http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			file, err := os.Open(filepath.Join(imagePath, "jalan.jpg"))
+			if err != nil {
+				return nil, err
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       file,
+				Header:     http.Header{"Content-Type": {"image/jpeg"}},
+			}, nil
+		}),
+	})
+	defer func() {
+		reportService.ImageFetcher = imageFetcher
+	}()
+
+	createUserDTO := &model.CreateUserDTO{
+		Name:        "sukirno",
+		PhoneNumber: "+6217340055590",
+		Email:       "sukirno@gmail.com",
+		Password:    "12345678",
+	}
+	userDTO, res := register(createUserDTO)
+
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	res = sendReport(t, userDTO.Token, map[string]string{
+		"lat":     "-6.966667",
+		"lng":     "110.416664",
+		"note":    "",
+		"address": "jalan pandanaran",
+	})
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	resBody, _ := ioutil.ReadAll(res.Body)
+	createReportResponse := struct {
+		Data *entity.Report `json:"data"`
+	}{}
+	json.Unmarshal(resBody, &createReportResponse)
+	path := fmt.Sprintf("/api/reports/%d/annotated.jpg", createReportResponse.Data.ID)
+	adminDTO := loginAdmin(t)
+
+	var etag string
+	t.Run("get annotated image", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+adminDTO.Token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		if got := res.Header().Get("Content-Type"); got != "image/jpeg" {
+			t.Errorf("Expecting content type to be image/jpeg but got %s instead", got)
+		}
+
+		if _, err := jpeg.Decode(res.Body); err != nil {
+			t.Errorf("Expecting a JPEG but got error %v", err)
+		}
+
+		etag = res.Header().Get("ETag")
+		if etag == "" {
+			t.Error("Expecting an ETag")
+		}
+	})
+
+	t.Run("get cached annotated image", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+adminDTO.Token)
+		req.Header.Set("If-None-Match", etag)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusNotModified, res.Code)
+	})
+
+	t.Run("get annotated image as non admin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+userDTO.Token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusForbidden, res.Code)
+	})
+}
+
+func TestReportHandlerRelabelReport(t *testing.T) {
+	reportService := reportSRV.(*service.ReportServiceImpl)
+	imageFetcher := reportService.ImageFetcher
+	reportService.ImageFetcher = service.NewHTTPImageFetcher(&http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			file, err := os.Open(filepath.Join(imagePath, "jalan.jpg"))
+			if err != nil {
+				return nil, err
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       file,
+				Header:     http.Header{"Content-Type": {"image/jpeg"}},
+			}, nil
+		}),
+	})
+	defer func() {
+		reportService.ImageFetcher = imageFetcher
+	}()
+
+	createUserDTO := &model.CreateUserDTO{
+		Name:        "suharti",
+		PhoneNumber: "+6217340055600",
+		Email:       "suharti@gmail.com",
+		Password:    "12345678",
+	}
+	userDTO, res := register(createUserDTO)
+
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	res = sendReport(t, userDTO.Token, map[string]string{
+		"lat":     "-7.250445",
+		"lng":     "112.768845",
+		"note":    "",
+		"address": "jalan tunjungan",
+	})
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	resBody, _ := ioutil.ReadAll(res.Body)
+	createReportResponse := struct {
+		Data *entity.Report `json:"data"`
+	}{}
+	json.Unmarshal(resBody, &createReportResponse)
+	report := createReportResponse.Data
+	path := fmt.Sprintf("/api/reports/%d/labels", report.ID)
+	adminDTO := loginAdmin(t)
+
+	sendLabels := func(t *testing.T, token string, body interface{}) *httptest.ResponseRecorder {
+		t.Helper()
+
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPut, path, bytes.NewBuffer(b))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		return res
+	}
+
+	t.Run("relabel report as non admin", func(t *testing.T) {
+		res := sendLabels(t, userDTO.Token, &model.RelabelReportDTO{
+			Classes: []string{"D10"},
+		})
+		assertResponseCode(t, http.StatusForbidden, res.Code)
+	})
+
+	t.Run("relabel report with invalid box", func(t *testing.T) {
+		res := sendLabels(t, adminDTO.Token, &model.RelabelReportDTO{
+			Detections: []*model.DetectionDTO{
+				{Class: "D20", Box: &model.BoxDTO{XMin: 50, YMin: 5, XMax: 10, YMax: 50}},
+			},
+		})
+		assertResponseCode(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("relabel report", func(t *testing.T) {
+		res := sendLabels(t, adminDTO.Token, &model.RelabelReportDTO{
+			Classes: []string{"D10"},
+			Detections: []*model.DetectionDTO{
+				{Class: "D20", Box: &model.BoxDTO{XMin: 5, YMin: 5, XMax: 50, YMax: 50}},
+			},
+		})
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data *entity.Report `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		if got := strings.Join(apiResponse.Data.Classes, ","); got != "D10,D20" {
+			t.Errorf("Expecting classes to be D10,D20 but got %s instead", got)
+		}
+
+		if got, want := strings.Join(apiResponse.Data.PredictedClasses, ","), strings.Join(report.Classes, ","); got != want {
+			t.Errorf("Expecting predicted classes to be %s but got %s instead", want, got)
+		}
+
+		if len(apiResponse.Data.Detections) != 1 || apiResponse.Data.Detections[0].Source != entity.DetectionSourceHuman {
+			t.Errorf("Expecting a single human detection but got %+v instead", apiResponse.Data.Detections)
+		}
+
+		if len(apiResponse.Data.PredictedDetections) != 2 {
+			t.Errorf("Expecting the length of predicted detections to be 2 but got %d instead", len(apiResponse.Data.PredictedDetections))
+		}
+
+		if apiResponse.Data.RelabeledAt == nil {
+			t.Error("Expecting relabeledAt to be set")
+		}
+	})
+
+	t.Run("export dataset", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/reports/dataset", nil)
+		req.Header.Set("Authorization", "Bearer "+adminDTO.Token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		zr, err := zip.NewReader(bytes.NewReader(res.Body.Bytes()), int64(res.Body.Len()))
+		if err != nil {
+			t.Fatalf("Expecting a valid zip archive, but got %v instead", err)
+		}
+
+		files := map[string]string{}
+		for _, f := range zr.File {
+			rc, _ := f.Open()
+			b, _ := ioutil.ReadAll(rc)
+			rc.Close()
+			files[f.Name] = string(b)
+		}
+
+		if _, ok := files[fmt.Sprintf("images/report-%d.jpg", report.ID)]; !ok {
+			t.Error("Expecting archive to contain the report image")
+		}
+
+		label := files[fmt.Sprintf("labels/report-%d.txt", report.ID)]
+		if !strings.HasPrefix(label, "4 ") || strings.Count(label, "\n") != 1 {
+			t.Errorf("Expecting a single D20 label but got %q instead", label)
+		}
+	})
+}
+
+func TestPredictionBackfillHandler(t *testing.T) {
+	backfillService := backfillSRV.(*service.PredictionBackfillServiceImpl)
+	imageFetcher := backfillService.ImageFetcher
+	backfillService.ImageFetcher = service.NewHTTPImageFetcher(&http.Client{
+		Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			file, err := os.Open(filepath.Join(imagePath, "jalan.jpg"))
+			if err != nil {
+				return nil, err
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       file,
+				Header:     http.Header{"Content-Type": {"image/jpeg"}},
+			}, nil
+		}),
+	})
+	defer func() {
+		backfillService.ImageFetcher = imageFetcher
+	}()
+
+	createUserDTO := &model.CreateUserDTO{
+		Name:        "sulastri",
+		PhoneNumber: "+6217340055610",
+		Email:       "sulastri@gmail.com",
+		Password:    "12345678",
+	}
+	userDTO, res := register(createUserDTO)
+
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	res = sendReport(t, userDTO.Token, map[string]string{
+		"lat":     "-8.670458",
+		"lng":     "115.212629",
+		"note":    "",
+		"address": "jalan gajah mada",
+	})
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	resBody, _ := ioutil.ReadAll(res.Body)
+	createReportResponse := struct {
+		Data *entity.Report `json:"data"`
+	}{}
+	json.Unmarshal(resBody, &createReportResponse)
+	report := createReportResponse.Data
+	adminDTO := loginAdmin(t)
+
+	if report.ModelVersion == nil || *report.ModelVersion != "test-v1" {
+		t.Errorf("Expecting the report to record the model version test-v1 but got %v instead", report.ModelVersion)
+	}
+
+	predictModelVersion = "test-v2"
+	defer func() {
+		predictModelVersion = "test-v1"
+	}()
+
+	runBackfill := func(t *testing.T, token string) (*entity.PredictionBackfill, *httptest.ResponseRecorder) {
+		t.Helper()
+
+		b, _ := json.Marshal(&model.CreatePredictionBackfillDTO{
+			ReportIDs: []int{report.ID},
+			Rate:      10,
+		})
+		req := httptest.NewRequest(http.MethodPost, "/api/backfills", bytes.NewBuffer(b))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		if res.Code != http.StatusAccepted {
+			return nil, res
+		}
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data *entity.PredictionBackfill `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		backfill := apiResponse.Data
+		for i := 0; i < 50 && backfill.Status == entity.BackfillStatusRunning; i++ {
+			time.Sleep(100 * time.Millisecond)
+
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/backfills/%d", backfill.ID), nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			res := httptest.NewRecorder()
+
+			router.ServeHTTP(res, req)
+
+			resBody, _ := ioutil.ReadAll(res.Body)
+			json.Unmarshal(resBody, &apiResponse)
+			backfill = apiResponse.Data
+		}
+
+		return backfill, res
+	}
+
+	t.Run("run backfill as non admin", func(t *testing.T) {
+		_, res := runBackfill(t, userDTO.Token)
+		assertResponseCode(t, http.StatusForbidden, res.Code)
+	})
+
+	t.Run("run backfill", func(t *testing.T) {
+		backfill, res := runBackfill(t, adminDTO.Token)
+		assertResponseCode(t, http.StatusAccepted, res.Code)
+
+		if backfill.Status != entity.BackfillStatusCompleted {
+			t.Fatalf("Expecting backfill to be completed but got %s instead", backfill.Status)
+		}
+
+		if backfill.Processed != 1 || backfill.Failed != 0 {
+			t.Errorf("Expecting 1 processed report but got %+v instead", backfill)
+		}
+
+		if backfill.ModelVersion == nil || *backfill.ModelVersion != "test-v2" {
+			t.Errorf("Expecting backfill model version to be test-v2 but got %v instead", backfill.ModelVersion)
+		}
+	})
+
+	t.Run("run backfill again", func(t *testing.T) {
+		backfill, res := runBackfill(t, adminDTO.Token)
+		assertResponseCode(t, http.StatusAccepted, res.Code)
+
+		if backfill.Processed != 0 || backfill.Skipped != 1 {
+			t.Errorf("Expecting the report to be skipped but got %+v instead", backfill)
+		}
+	})
+
+	t.Run("get report predictions", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/reports/%d/predictions", report.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+adminDTO.Token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data []*entity.ReportPrediction `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		if len(apiResponse.Data) != 1 {
+			t.Fatalf("Expecting the length of predictions to be 1 but got %d instead", len(apiResponse.Data))
+		}
+
+		if apiResponse.Data[0].ModelVersion != "test-v2" {
+			t.Errorf("Expecting prediction model version to be test-v2 but got %s instead", apiResponse.Data[0].ModelVersion)
+		}
+
+		if len(apiResponse.Data[0].Detections) != 2 {
+			t.Errorf("Expecting the length of predicted detections to be 2 but got %d instead", len(apiResponse.Data[0].Detections))
+		}
+	})
+
+	t.Run("original prediction is kept", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/reports/%d", report.ID), nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data *entity.Report `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		if apiResponse.Data.ModelVersion == nil || *apiResponse.Data.ModelVersion != "test-v1" {
+			t.Errorf("Expecting the report model version to stay test-v1 but got %v instead", apiResponse.Data.ModelVersion)
+		}
+	})
+}
+
+func TestReportHandlerShadowPredictions(t *testing.T) {
+	reportService := reportSRV.(*service.ReportServiceImpl)
+	reportService.ShadowPredictor = service.NewFakePredictor(&model.PredictResult{
+		ImageUrl:     "https://storage.googleapis.com/test/shadow.jpg",
+		Classes:      []string{"D00", "D20"},
+		Score:        80,
+		ModelVersion: "shadow-v1",
+	})
+	defer func() {
+		reportService.ShadowPredictor = nil
+	}()
+
+	createUserDTO := &model.CreateUserDTO{
+		Name:        "sukinah",
+		PhoneNumber: "+6217340055620",
+		Email:       "sukinah@gmail.com",
+		Password:    "12345678",
+	}
+	userDTO, res := register(createUserDTO)
+
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	res = sendReport(t, userDTO.Token, map[string]string{
+		"lat":     "-0.789275",
+		"lng":     "113.921327",
+		"note":    "",
+		"address": "jalan tjilik riwut",
+	})
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	resBody, _ := ioutil.ReadAll(res.Body)
+	createReportResponse := struct {
+		Data *entity.Report `json:"data"`
+	}{}
+	json.Unmarshal(resBody, &createReportResponse)
+	report := createReportResponse.Data
+	adminDTO := loginAdmin(t)
+
+	t.Run("store shadow prediction", func(t *testing.T) {
+		var predictions []*entity.ReportPrediction
+		for i := 0; i < 50 && len(predictions) == 0; i++ {
+			time.Sleep(100 * time.Millisecond)
+
+			req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/reports/%d/predictions", report.ID), nil)
+			req.Header.Set("Authorization", "Bearer "+adminDTO.Token)
+			res := httptest.NewRecorder()
+
+			router.ServeHTTP(res, req)
+
+			resBody, _ := ioutil.ReadAll(res.Body)
+			apiResponse := struct {
+				Data []*entity.ReportPrediction `json:"data"`
+			}{}
+			json.Unmarshal(resBody, &apiResponse)
+			predictions = apiResponse.Data
+		}
+
+		if len(predictions) != 1 {
+			t.Fatalf("Expecting the length of predictions to be 1 but got %d instead", len(predictions))
+		}
+
+		if !predictions[0].Shadow || predictions[0].ModelVersion != "shadow-v1" {
+			t.Errorf("Expecting a shadow prediction from shadow-v1 but got %+v instead", predictions[0])
+		}
+	})
+
+	t.Run("get shadow agreement", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/reports/shadow-agreement?modelVersion=shadow-v1", nil)
+		req.Header.Set("Authorization", "Bearer "+adminDTO.Token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data *model.ShadowAgreement `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		if apiResponse.Data.Total != 1 || apiResponse.Data.ExactMatches != 0 {
+			t.Errorf("Expecting 1 compared report without exact match but got %+v instead", apiResponse.Data)
+		}
+
+		classes := map[string]*model.ClassAgreement{}
+		for _, class := range apiResponse.Data.Classes {
+			classes[class.Class] = class
+		}
+
+		if classes["D00"] == nil || classes["D00"].Both != 1 || classes["D00"].Agreement != 1 {
+			t.Errorf("Expecting both models to agree on D00 but got %+v instead", classes["D00"])
+		}
+
+		if classes["D01"] == nil || classes["D01"].PrimaryOnly != 1 || classes["D01"].Agreement != 0 {
+			t.Errorf("Expecting only the primary model to find D01 but got %+v instead", classes["D01"])
+		}
+	})
+
+	t.Run("get shadow agreement without model version", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/reports/shadow-agreement", nil)
+		req.Header.Set("Authorization", "Bearer "+adminDTO.Token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("get shadow agreement as non admin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/reports/shadow-agreement", nil)
+		req.Header.Set("Authorization", "Bearer "+userDTO.Token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusForbidden, res.Code)
+	})
+}
+
+func TestReportHandlerReviewQueue(t *testing.T) {
+	reportService := reportSRV.(*service.ReportServiceImpl)
+	predictor := reportService.Predictor
+	reportService.Predictor = service.NewFakePredictor(&model.PredictResult{
+		ImageUrl:     "https://storage.googleapis.com/test/wall.jpg",
+		ModelVersion: predictModelVersion,
+	})
+	defer func() {
+		reportService.Predictor = predictor
+	}()
+
+	createUserDTO := &model.CreateUserDTO{
+		Name:        "sutrisno",
+		PhoneNumber: "+6217340055630",
+		Email:       "sutrisno@gmail.com",
+		Password:    "12345678",
+	}
+	userDTO, res := register(createUserDTO)
+
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	res = sendReport(t, userDTO.Token, map[string]string{
+		"lat":     "-0.789275",
+		"lng":     "113.921327",
+		"note":    "",
+		"address": "jalan g. obos",
+	})
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	resBody, _ := ioutil.ReadAll(res.Body)
+	createReportResponse := struct {
+		Message string         `json:"message"`
+		Data    *entity.Report `json:"data"`
+	}{}
+	json.Unmarshal(resBody, &createReportResponse)
+	report := createReportResponse.Data
+	adminDTO := loginAdmin(t)
+
+	if report.Status != service.StatusNeedsReview || report.ReviewReason != entity.ReviewReasonNoDamage {
+		t.Fatalf("Expecting a report waiting for review without damage but got %+v instead", report)
+	}
+
+	if createReportResponse.Message == "Created" {
+		t.Errorf("Expecting a message telling the report is waiting for review")
+	}
+
+	listsReport := func(t *testing.T, target, token string) bool {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data []*entity.Report `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		for _, r := range apiResponse.Data {
+			if r.ID == report.ID {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	t.Run("hide from public listing", func(t *testing.T) {
+		if listsReport(t, "/api/reports?limit=100", "") {
+			t.Errorf("Expecting report %d waiting for review to be hidden", report.ID)
+		}
+	})
+
+	t.Run("list in reporter history", func(t *testing.T) {
+		if !listsReport(t, "/api/reports/history?limit=100", userDTO.Token) {
+			t.Errorf("Expecting report %d in the history of its reporter", report.ID)
+		}
+	})
+
+	t.Run("list in review queue", func(t *testing.T) {
+		if !listsReport(t, "/api/reports/review?limit=100", adminDTO.Token) {
+			t.Errorf("Expecting report %d in the review queue", report.ID)
+		}
+	})
+
+	t.Run("get report waiting for review", func(t *testing.T) {
+		reportPath := fmt.Sprintf("/api/reports/%d", report.ID)
+
+		req := httptest.NewRequest(http.MethodGet, reportPath, nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusNotFound, res.Code)
+
+		for _, token := range []string{userDTO.Token, adminDTO.Token} {
+			req := httptest.NewRequest(http.MethodGet, reportPath, nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			res := httptest.NewRecorder()
+
+			router.ServeHTTP(res, req)
+
+			assertResponseCode(t, http.StatusOK, res.Code)
+		}
+	})
+
+	t.Run("get review queue as non admin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/reports/review", nil)
+		req.Header.Set("Authorization", "Bearer "+userDTO.Token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusForbidden, res.Code)
+	})
+
+	t.Run("approve report", func(t *testing.T) {
+		updateReportDTO := &model.UpdateReportDTO{
+			Status: "Reported",
+		}
+		b, _ := json.Marshal(updateReportDTO)
+		req := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/api/reports/%d", report.ID), bytes.NewBuffer(b))
+		req.Header.Set("Authorization", "Bearer "+adminDTO.Token)
+		req.Header.Set("Content-Type", "application/json")
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		if listsReport(t, "/api/reports/review?limit=100", adminDTO.Token) {
+			t.Errorf("Expecting report %d to leave the review queue", report.ID)
+		}
+
+		if !listsReport(t, "/api/reports?limit=100", "") {
+			t.Errorf("Expecting approved report %d in the public listing", report.ID)
+		}
+	})
+
+	t.Run("list withdrawn report waiting for review", func(t *testing.T) {
+		res := sendReport(t, userDTO.Token, map[string]string{
+			"lat":     "-0.799275",
+			"lng":     "113.921327",
+			"note":    "",
+			"address": "jalan g. obos ujung",
+		})
+		assertResponseCode(t, http.StatusCreated, res.Code)
+
+		resBody, _ := ioutil.ReadAll(res.Body)
+		json.Unmarshal(resBody, &createReportResponse)
+		withdrawn := createReportResponse.Data
+
+		if withdrawn.Status != service.StatusNeedsReview {
+			t.Fatalf("Expecting a report waiting for review but got %s instead", withdrawn.Status)
+		}
+
+		req := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/api/reports/%d", withdrawn.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+userDTO.Token)
+		res = httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		req = httptest.NewRequest(http.MethodGet, "/api/reports/withdrawn?limit=100", nil)
+		req.Header.Set("Authorization", "Bearer "+adminDTO.Token)
+		res = httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		resBody, _ = ioutil.ReadAll(res.Body)
+		apiResponse := struct {
+			Data []*entity.Report `json:"data"`
+		}{}
+		json.Unmarshal(resBody, &apiResponse)
+
+		for _, r := range apiResponse.Data {
+			if r.ID == withdrawn.ID {
+				return
+			}
+		}
+		t.Errorf("Expecting withdrawn report %d waiting for review to be listed", withdrawn.ID)
+	})
+}
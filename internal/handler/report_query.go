@@ -0,0 +1,278 @@
+package handler
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+)
+
+const (
+	defaultNearRadius = 1000
+	maxNearRadius     = 50000
+	maxZoom           = 22
+)
+
+const dateLayout = "2006-01-02"
+
+func parsePagination(op string, query url.Values) (*model.Pagination, error) {
+	pagination := new(model.Pagination)
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.ParseUint(limitStr, 10, 64)
+		if err != nil {
+			return nil, api.NewSingleMessageException(
+				api.EINVALID,
+				op,
+				"Invalid limit argument",
+				err,
+			)
+		}
+		pagination.Limit = limit
+	}
+
+	if lastseenIDStr := query.Get("lastseenid"); lastseenIDStr != "" {
+		lastseenID, err := strconv.ParseUint(lastseenIDStr, 10, 64)
+		if err != nil {
+			return nil, api.NewSingleMessageException(
+				api.EINVALID,
+				op,
+				"Invalid lastseenid argument",
+				err,
+			)
+		}
+		pagination.LastseenID = lastseenID
+	}
+
+	return pagination, nil
+}
+
+func parseReportFilter(op string, query url.Values) (*model.ReportFilter, error) {
+	filter := &model.ReportFilter{
+		Query:    strings.TrimSpace(query.Get("q")),
+		Statuses: parseList(query["status"]),
+		Classes:  parseList(query["class"]),
+		Sort:     query.Get("sort"),
+		Order:    query.Get("order"),
+	}
+
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err := parseDate(fromStr, false)
+		if err != nil {
+			return nil, api.NewSingleMessageException(
+				api.EINVALID,
+				op,
+				"Invalid from argument. from must be a date (YYYY-MM-DD) or RFC3339 timestamp",
+				err,
+			)
+		}
+		filter.From = &from
+	}
+
+	if toStr := query.Get("to"); toStr != "" {
+		to, err := parseDate(toStr, true)
+		if err != nil {
+			return nil, api.NewSingleMessageException(
+				api.EINVALID,
+				op,
+				"Invalid to argument. to must be a date (YYYY-MM-DD) or RFC3339 timestamp",
+				err,
+			)
+		}
+		filter.To = &to
+	}
+
+	if reporterStr := query.Get("reporter"); reporterStr != "" {
+		reporterID, err := strconv.Atoi(reporterStr)
+		if err != nil {
+			return nil, api.NewSingleMessageException(
+				api.EINVALID,
+				op,
+				"Invalid reporter argument",
+				err,
+			)
+		}
+		filter.ReporterID = reporterID
+	}
+
+	if minScoreStr := query.Get("minScore"); minScoreStr != "" {
+		minScore, err := strconv.ParseFloat(minScoreStr, 64)
+		if err != nil {
+			return nil, api.NewSingleMessageException(
+				api.EINVALID,
+				op,
+				"Invalid minScore argument. minScore must be a number",
+				err,
+			)
+		}
+		filter.MinScore = &minScore
+	}
+
+	if maxScoreStr := query.Get("maxScore"); maxScoreStr != "" {
+		maxScore, err := strconv.ParseFloat(maxScoreStr, 64)
+		if err != nil {
+			return nil, api.NewSingleMessageException(
+				api.EINVALID,
+				op,
+				"Invalid maxScore argument. maxScore must be a number",
+				err,
+			)
+		}
+		filter.MaxScore = &maxScore
+	}
+
+	if duplicatesStr := query.Get("includeDuplicates"); duplicatesStr != "" {
+		includeDuplicates, err := strconv.ParseBool(duplicatesStr)
+		if err != nil {
+			return nil, api.NewSingleMessageException(
+				api.EINVALID,
+				op,
+				"Invalid includeDuplicates argument. includeDuplicates must be a boolean",
+				err,
+			)
+		}
+		filter.IncludeDuplicates = includeDuplicates
+	}
+
+	geoFilter, err := parseGeoFilter(op, query)
+	if err != nil {
+		return nil, err
+	}
+	filter.Geo = geoFilter
+
+	return filter, nil
+}
+
+// parseList accepts both repeated parameters and comma separated values.
+func parseList(values []string) []string {
+	var list []string
+	for _, v := range values {
+		for _, item := range strings.Split(v, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+	}
+
+	return list
+}
+
+// parseDate parses a date or an RFC3339 timestamp. A plain date used as the
+// end of a range is moved to the following midnight so the whole day is
+// included.
+func parseDate(s string, endOfRange bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(dateLayout, s)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if endOfRange {
+		t = t.AddDate(0, 0, 1)
+	}
+
+	return t, nil
+}
+
+func parseGeoFilter(op string, query url.Values) (*model.GeoFilter, error) {
+	nearStr := query.Get("near")
+	radiusStr := query.Get("radius")
+	bboxStr := query.Get("bbox")
+
+	if nearStr == "" && radiusStr != "" {
+		return nil, api.NewSingleMessageException(
+			api.EINVALID,
+			op,
+			"radius argument requires near argument",
+			errors.New("radius without near"),
+		)
+	}
+
+	if nearStr == "" && bboxStr == "" {
+		return nil, nil
+	}
+
+	geoFilter := new(model.GeoFilter)
+	if nearStr != "" {
+		coords, err := parseFloats(nearStr, 2)
+		if err != nil || !validLatLng(coords[0], coords[1]) {
+			return nil, api.NewSingleMessageException(
+				api.EINVALID,
+				op,
+				"Invalid near argument. near must be in lat,lng format",
+				errors.New("invalid near argument"),
+			)
+		}
+		geoFilter.Near = &entity.Location{
+			Lat: coords[0],
+			Lng: coords[1],
+		}
+
+		geoFilter.Radius = defaultNearRadius
+		if radiusStr != "" {
+			radius, err := strconv.ParseFloat(radiusStr, 64)
+			if err != nil || radius <= 0 || radius > maxNearRadius {
+				return nil, api.NewSingleMessageException(
+					api.EINVALID,
+					op,
+					"Invalid radius argument. radius must be between 0 and 50000 meters",
+					errors.New("invalid radius argument"),
+				)
+			}
+			geoFilter.Radius = radius
+		}
+	}
+
+	if bboxStr != "" {
+		coords, err := parseFloats(bboxStr, 4)
+		if err != nil ||
+			!validLatLng(coords[1], coords[0]) ||
+			!validLatLng(coords[3], coords[2]) ||
+			coords[0] >= coords[2] ||
+			coords[1] >= coords[3] {
+			return nil, api.NewSingleMessageException(
+				api.EINVALID,
+				op,
+				"Invalid bbox argument. bbox must be in minLng,minLat,maxLng,maxLat format",
+				errors.New("invalid bbox argument"),
+			)
+		}
+		geoFilter.BBox = &model.BoundingBox{
+			MinLng: coords[0],
+			MinLat: coords[1],
+			MaxLng: coords[2],
+			MaxLat: coords[3],
+		}
+	}
+
+	return geoFilter, nil
+}
+
+func parseFloats(s string, n int) ([]float64, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != n {
+		return nil, errors.New("unexpected number of values")
+	}
+
+	values := make([]float64, n)
+	for k, v := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return nil, err
+		}
+		values[k] = f
+	}
+
+	return values, nil
+}
+
+func validLatLng(lat, lng float64) bool {
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180
+}
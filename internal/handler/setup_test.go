@@ -42,6 +42,14 @@ var (
 
 var admin *model.LoginDTO
 
+var reportSRV service.ReportService
+
+var backfillSRV service.PredictionBackfillService
+
+// predictModelVersion is the model version reported by the mock predict
+// server.
+var predictModelVersion = "test-v1"
+
 func TestMain(m *testing.M) {
 	router = chi.NewRouter()
 	db := newTestDatabase()
@@ -65,10 +73,26 @@ func TestMain(m *testing.M) {
 	userHandler.Route(router)
 
 	reportRepo := repository.NewReportRepository()
-	predictAPIURL := mockPredictServer.URL
-	reportSRV := service.NewReportService(configApp, reportRepo, userRepo, predictAPIURL)
+	historyRepo := repository.NewReportStatusHistoryRepository()
+	confirmationRepo := repository.NewReportConfirmationRepository()
+	detectionRepo := repository.NewReportDetectionRepository()
+	predictionRepo := repository.NewReportPredictionRepository()
+	predictor := service.NewResilientPredictor(service.NewHTTPPredictor(mockPredictServer.URL), 8, 3)
+	healthHandler := NewHealthHandler(predictor)
+	healthHandler.Route(router)
+	reportSRV = service.NewReportService(configApp, reportRepo, userRepo, historyRepo, confirmationRepo, detectionRepo, predictionRepo, predictor, nil, 0, nil)
 	reportHandler := NewReportHandler(val, reportSRV)
 	reportHandler.Route(router)
+	tileHandler := NewTileHandler(val, reportSRV)
+	tileHandler.Route(router)
+	commentRepo := repository.NewReportCommentRepository()
+	commentSRV := service.NewReportCommentService(configApp, reportRepo, commentRepo)
+	commentHandler := NewReportCommentHandler(val, commentSRV)
+	commentHandler.Route(router)
+	backfillRepo := repository.NewPredictionBackfillRepository()
+	backfillSRV = service.NewPredictionBackfillService(configApp, reportRepo, predictionRepo, backfillRepo, predictor, service.NewHTTPImageFetcher(http.DefaultClient))
+	backfillHandler := NewPredictionBackfillHandler(val, backfillSRV)
+	backfillHandler.Route(router)
 
 	adminCreateUserDTO := &model.CreateUserDTO{
 		Name:        "yahahaha",
@@ -103,7 +127,7 @@ func newTestDatabase() *sql.DB {
 		log.Fatalf("Could not connect to docker: %s", err)
 	}
 
-	resource, err = pool.Run("postgres", "alpine", []string{"POSTGRES_PASSWORD=secret", "POSTGRES_DB=postgres"})
+	resource, err = pool.Run("postgis/postgis", "13-3.1-alpine", []string{"POSTGRES_PASSWORD=secret", "POSTGRES_DB=postgres"})
 	if err != nil {
 		log.Fatalf("Could not start resource: %s", err)
 	}
@@ -141,10 +165,28 @@ func mockPredictServer() *httptest.Server {
 		switch format {
 		case "jpg", "jpeg", "png":
 			classes := []string{"D00", "D01", "D10", "D11", "D20", "D40", "D43", "D44", "D50"}
+			classScores := map[string]float64{}
+			for k, v := range classes {
+				classScores[v] = float64(90 - k)
+			}
 			predictResult := &model.PredictResult{
-				ImageUrl: "https://storage.googleapis.com/test/predict.jpg",
-				Classes:  classes,
-				Score:    90,
+				ImageUrl:     "https://storage.googleapis.com/test/predict.jpg",
+				Classes:      classes,
+				Score:        90,
+				ModelVersion: predictModelVersion,
+				ClassScores:  classScores,
+				Detections: []*entity.ReportDetection{
+					{
+						Class: "D40",
+						Score: 90,
+						Box:   &entity.Box{XMin: 10, YMin: 20, XMax: 110, YMax: 80},
+					},
+					{
+						Class: "D00",
+						Score: 75,
+						Box:   &entity.Box{XMin: 200, YMin: 40, XMax: 260, YMax: 300},
+					},
+				},
 			}
 
 			api.NewResponse(http.StatusOK, "OK", predictResult).SendJSON(w)
@@ -210,3 +252,23 @@ func createAdmin(createUserDTO *model.CreateUserDTO, db *sql.DB) (*entity.User,
 
 	return newUser, nil
 }
+
+func loginAdmin(t testing.TB) *model.UserDTO {
+	t.Helper()
+
+	b, _ := json.Marshal(admin)
+	req := httptest.NewRequest(http.MethodPost, "/api/users/login", bytes.NewBuffer(b))
+	req.Header.Set("Content-Type", "application/json")
+	res := httptest.NewRecorder()
+
+	router.ServeHTTP(res, req)
+	assertResponseCode(t, http.StatusOK, res.Code)
+
+	resBody, _ := ioutil.ReadAll(res.Body)
+	apiResponse := struct {
+		Data *model.UserDTO `json:"data"`
+	}{}
+	json.Unmarshal(resBody, &apiResponse)
+
+	return apiResponse.Data
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/service"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/validation"
+)
+
+const (
+	mvtContentType   = "application/vnd.mapbox-vector-tile"
+	tileCacheControl = "public, max-age=300"
+)
+
+type TileHandler struct {
+	*validation.Validator
+	service.ReportService
+}
+
+func NewTileHandler(
+	val *validation.Validator,
+	reportSRV service.ReportService,
+) *TileHandler {
+	return &TileHandler{
+		Validator:     val,
+		ReportService: reportSRV,
+	}
+}
+
+func (h *TileHandler) Route(mux *chi.Mux) {
+	mux.Route("/api/tiles", func(r chi.Router) {
+		r.Get("/reports/{z}/{x}/{y}.mvt", h.GetReportTile)
+	})
+}
+
+func (h *TileHandler) GetReportTile(w http.ResponseWriter, r *http.Request) {
+	const op = "TileHandler.GetReportTile"
+	z, errZ := strconv.Atoi(chi.URLParam(r, "z"))
+	x, errX := strconv.Atoi(chi.URLParam(r, "x"))
+	y, errY := strconv.Atoi(chi.URLParam(r, "y"))
+	if errZ != nil || errX != nil || errY != nil || !validTile(z, x, y) {
+		exc := api.NewSingleMessageException(
+			api.EINVALID,
+			op,
+			"Invalid tile coordinate",
+			errors.New("invalid tile coordinate"),
+		)
+		api.SendError(w, exc)
+		return
+	}
+
+	filter, err := parseReportFilter(op, r.URL.Query())
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	if err := h.Validate(op, filter); err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	tile, err := h.ReportService.GetTile(r.Context(), z, x, y, filter)
+	if err != nil {
+		api.SendError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", mvtContentType)
+	w.Header().Set("Cache-Control", tileCacheControl)
+	w.WriteHeader(http.StatusOK)
+	w.Write(tile)
+}
+
+func validTile(z, x, y int) bool {
+	if z < 0 || z > maxZoom {
+		return false
+	}
+
+	n := 1 << uint(z)
+	return x >= 0 && x < n && y >= 0 && y < n
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+)
+
+func TestTileHandlerGetReportTile(t *testing.T) {
+	createUserDTO := &model.CreateUserDTO{
+		Name:        "sutinah",
+		PhoneNumber: "+6217340033310",
+		Email:       "sutinah@gmail.com",
+		Password:    "12345678",
+	}
+	userDTO, res := register(createUserDTO)
+
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	res = sendReport(t, userDTO.Token, map[string]string{
+		"lat":     "-7.666369905243495",
+		"lng":     "110.66331442645793",
+		"note":    "",
+		"address": "mataram",
+	})
+	assertResponseCode(t, http.StatusCreated, res.Code)
+
+	t.Run("get tile normally", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/tiles/reports/0/0/0.mvt", nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusOK, res.Code)
+
+		if contentType := res.Header().Get("Content-Type"); contentType != mvtContentType {
+			t.Errorf("Expecting content type to be %q but got %q instead", mvtContentType, contentType)
+		}
+
+		if cacheControl := res.Header().Get("Cache-Control"); cacheControl == "" {
+			t.Error("Expecting cache control header to be set")
+		}
+
+		if res.Body.Len() == 0 {
+			t.Error("Expecting tile to be not empty")
+		}
+	})
+
+	t.Run("get tile outside of the zoom level", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/tiles/reports/1/2/0.mvt", nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusBadRequest, res.Code)
+	})
+
+	t.Run("get tile with invalid coordinate", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/tiles/reports/a/0/0.mvt", nil)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		assertResponseCode(t, http.StatusBadRequest, res.Code)
+	})
+}
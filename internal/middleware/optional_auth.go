@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// OptionalAuth lets anonymous requests through, but authenticates the ones
+// with an Authorization header like RequireAuth does.
+func OptionalAuth(next http.Handler) http.Handler {
+	requireAuth := RequireAuth(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Authorization"), "Bearer") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		requireAuth.ServeHTTP(w, r)
+	})
+}
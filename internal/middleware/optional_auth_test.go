@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/utils"
+)
+
+func TestOptionalAuth(t *testing.T) {
+	token, _ := utils.CreateToken(&model.UserPayload{ID: 1, Email: "bambank@gmai.com"})
+	cases := []struct {
+		name  string
+		token string
+		want  int
+	}{
+		{"Request without Authorization header", "", http.StatusNoContent},
+		{"Pass invalid token", "invalid", http.StatusUnauthorized},
+		{"Pass valid token", token, http.StatusOK},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/optional", nil)
+			if c.token != "" {
+				req.Header.Set("Authorization", "Bearer "+c.token)
+			}
+			res := httptest.NewRecorder()
+
+			router.ServeHTTP(res, req)
+
+			if res.Code != c.want {
+				t.Errorf("Expecting status code to be %d, but got %d instead", c.want, res.Code)
+			}
+		})
+	}
+}
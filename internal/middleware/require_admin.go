@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
+)
+
+// RequireAdmin must be mounted after RequireAuth.
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userPayload, err := api.UserPayloadFromContext("RequireAdmin", r)
+		if err != nil {
+			api.SendError(w, err)
+			return
+		}
+
+		if userPayload.Role != "ADMIN" {
+			exc := api.NewSingleMessageException(
+				api.EFORBIDDEN,
+				"RequireAdmin",
+				"Forbidden",
+				errors.New("trying to access admin endpoint without valid credential"),
+			)
+			api.SendError(w, exc)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/utils"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	t.Run("Pass admin token", func(t *testing.T) {
+		userPayload := &model.UserPayload{
+			ID:    1,
+			Email: "herman@gmail.com",
+			Role:  "ADMIN",
+		}
+		token, _ := utils.CreateToken(userPayload)
+
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		got := res.Code
+		want := http.StatusOK
+
+		if got != want {
+			t.Errorf("Expecting status code to be %d, but got %d instead", want, got)
+		}
+	})
+
+	t.Run("Pass user token", func(t *testing.T) {
+		userPayload := &model.UserPayload{
+			ID:    2,
+			Email: "bambank@gmai.com",
+			Role:  "USER",
+		}
+		token, _ := utils.CreateToken(userPayload)
+
+		req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		res := httptest.NewRecorder()
+
+		router.ServeHTTP(res, req)
+
+		got := res.Code
+		want := http.StatusForbidden
+
+		if got != want {
+			t.Errorf("Expecting status code to be %d, but got %d instead", want, got)
+		}
+	})
+}
@@ -17,6 +17,8 @@ func TestMain(m *testing.M) {
 	os.Setenv("JWT_KEY", "12345678")
 
 	router.With(RequireAuth).Get("/tokens", testRequireAuthHandler)
+	router.With(RequireAuth, RequireAdmin).Get("/admin", testRequireAuthHandler)
+	router.With(OptionalAuth).Get("/optional", testOptionalAuthHandler)
 
 	os.Exit(m.Run())
 }
@@ -30,3 +32,12 @@ func testRequireAuthHandler(w http.ResponseWriter, r *http.Request) {
 
 	api.NewResponse(http.StatusOK, "OK", userPayload)
 }
+
+func testOptionalAuthHandler(w http.ResponseWriter, r *http.Request) {
+	if _, err := api.UserPayloadFromContext("", r); err != nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
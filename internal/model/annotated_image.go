@@ -0,0 +1,7 @@
+package model
+
+type AnnotatedImage struct {
+	Data []byte
+	// ETag changes whenever the photo or its detections change.
+	ETag string
+}
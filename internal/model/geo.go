@@ -0,0 +1,37 @@
+package model
+
+import "gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+
+type BoundingBox struct {
+	MinLng float64
+	MinLat float64
+	MaxLng float64
+	MaxLat float64
+}
+
+type GeoFilter struct {
+	Near   *entity.Location
+	Radius float64
+	BBox   *BoundingBox
+}
+
+// Origin returns the point results are sorted by distance from. It is the
+// near point when given, otherwise the center of the bounding box.
+func (f *GeoFilter) Origin() *entity.Location {
+	if f == nil {
+		return nil
+	}
+
+	if f.Near != nil {
+		return f.Near
+	}
+
+	if f.BBox != nil {
+		return &entity.Location{
+			Lat: (f.BBox.MinLat + f.BBox.MaxLat) / 2,
+			Lng: (f.BBox.MinLng + f.BBox.MaxLng) / 2,
+		}
+	}
+
+	return nil
+}
@@ -1,7 +1,15 @@
 package model
 
+import "gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+
 type PredictResult struct {
 	ImageUrl string   `json:"imageUrl"`
 	Classes  []string `json:"classes"`
 	Score    float64  `json:"score"`
+	// ModelVersion identifies the model that made the prediction.
+	ModelVersion string `json:"modelVersion"`
+	// ClassScores holds the confidence of every detected class.
+	ClassScores map[string]float64 `json:"classScores"`
+	// Detections locates every damage found in the photo.
+	Detections []*entity.ReportDetection `json:"detections"`
 }
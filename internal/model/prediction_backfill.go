@@ -0,0 +1,9 @@
+package model
+
+// CreatePredictionBackfillDTO selects reports by id on top of the report
+// filter given in the query string. Rate is the number of predictions per
+// second.
+type CreatePredictionBackfillDTO struct {
+	ReportIDs []int   `json:"reportIds" validate:"max=10000,dive,min=1"`
+	Rate      float64 `json:"rate" validate:"omitempty,min=0.01,max=10"`
+}
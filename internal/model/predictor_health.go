@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// PredictorHealth is the state of the circuit breaker and concurrency limit in
+// front of the predict service. State is closed while predictions go through,
+// open while they fail fast and half-open while a single prediction probes
+// whether the service has recovered.
+type PredictorHealth struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	InFlight            int        `json:"inFlight"`
+	MaxConcurrency      int        `json:"maxConcurrency"`
+	OpenedAt            *time.Time `json:"openedAt,omitempty"`
+}
+
+type Health struct {
+	Predictor *PredictorHealth `json:"predictor"`
+}
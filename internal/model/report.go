@@ -9,4 +9,43 @@ type CreateReportDTO struct {
 
 type UpdateReportDTO struct {
 	Status string `json:"status" validate:"oneof='Reported' 'Under Repair' 'Completed' 'Rejected'"`
+	Note   string `json:"note" validate:"max=1000"`
+}
+
+// EditReportDTO holds the details a reporter can correct. Nil fields are left
+// unchanged.
+type EditReportDTO struct {
+	Note     *string      `json:"note" validate:"omitempty,max=1000"`
+	Address  *string      `json:"address" validate:"omitempty,min=4"`
+	Location *LocationDTO `json:"location"`
+}
+
+// LocationDTO uses pointers so that a missing coordinate is told apart from
+// the equator or the prime meridian.
+type LocationDTO struct {
+	Lat *float64 `json:"lat" validate:"required,min=-90,max=90"`
+	Lng *float64 `json:"lng" validate:"required,min=-180,max=180"`
+}
+
+type MergeReportDTO struct {
+	CanonicalID int `json:"canonicalId" validate:"required,min=1"`
+}
+
+// RelabelReportDTO holds an admin's correction of a report's prediction. When
+// Detections is nil the detections are left unchanged.
+type RelabelReportDTO struct {
+	Classes    []string        `json:"classes" validate:"required_without=Detections,dive,oneof=D00 D01 D10 D11 D20 D40 D43 D44 D50"`
+	Detections []*DetectionDTO `json:"detections" validate:"dive,required"`
+}
+
+type DetectionDTO struct {
+	Class string  `json:"class" validate:"oneof=D00 D01 D10 D11 D20 D40 D43 D44 D50"`
+	Box   *BoxDTO `json:"box" validate:"required"`
+}
+
+type BoxDTO struct {
+	XMin float64 `json:"xMin" validate:"min=0"`
+	YMin float64 `json:"yMin" validate:"min=0"`
+	XMax float64 `json:"xMax" validate:"gtfield=XMin"`
+	YMax float64 `json:"yMax" validate:"gtfield=YMin"`
 }
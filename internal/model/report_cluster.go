@@ -0,0 +1,18 @@
+package model
+
+import "gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+
+type ReportCluster struct {
+	Count         int              `json:"count"`
+	Location      *entity.Location `json:"location"`
+	DominantClass string           `json:"dominantClass"`
+	ReportID      *int             `json:"reportId,omitempty"`
+}
+
+// ReportClusters holds either clusters or, once zoomed in far enough,
+// individual reports.
+type ReportClusters struct {
+	Zoom     int              `json:"zoom"`
+	Clusters []*ReportCluster `json:"clusters"`
+	Reports  []*entity.Report `json:"reports"`
+}
@@ -0,0 +1,6 @@
+package model
+
+type CreateReportCommentDTO struct {
+	Body     string `json:"body" validate:"required,max=2000"`
+	Internal bool   `json:"internal"`
+}
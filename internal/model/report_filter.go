@@ -0,0 +1,41 @@
+package model
+
+import "time"
+
+type ReportFilter struct {
+	// IDs restricts the filter to the given reports.
+	IDs        []int    `validate:"dive,min=1"`
+	Query      string   `validate:"max=200"`
+	Statuses   []string `validate:"dive,oneof='Needs Review' 'Reported' 'Under Repair' 'Completed' 'Rejected'"`
+	Classes    []string `validate:"dive,oneof=D00 D01 D10 D11 D20 D40 D43 D44 D50"`
+	From       *time.Time
+	To         *time.Time
+	ReporterID int `validate:"min=0"`
+	Geo        *GeoFilter
+	// IncludeDuplicates also lists reports linked to a canonical report.
+	IncludeDuplicates bool
+	// Withdrawn lists reports withdrawn by their reporter instead of active
+	// ones.
+	Withdrawn bool
+	// NeedsReview lists the reports waiting for an admin to review their
+	// prediction instead of published ones.
+	NeedsReview bool
+	// IncludeNeedsReview also lists reports waiting for review, which are
+	// otherwise only shown to their reporter and in the review queue.
+	IncludeNeedsReview bool
+	// Relabeled only lists reports whose detections were corrected by an
+	// admin.
+	Relabeled bool
+	// Sort overrides the default order of a listing, highest first unless
+	// Order is asc.
+	Sort     string   `validate:"omitempty,oneof=confirmations score"`
+	Order    string   `validate:"omitempty,oneof=asc desc"`
+	MinScore *float64 `validate:"omitempty,min=0"`
+	MaxScore *float64 `validate:"omitempty,min=0"`
+}
+
+type ReportFacets struct {
+	Total    int            `json:"total"`
+	Statuses map[string]int `json:"statuses"`
+	Classes  map[string]int `json:"classes"`
+}
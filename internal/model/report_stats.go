@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+type ReportStats struct {
+	Total    int                  `json:"total"`
+	Statuses map[string]int       `json:"statuses"`
+	Classes  map[string]int       `json:"classes"`
+	Interval string               `json:"interval"`
+	Series   []*ReportStatsBucket `json:"series"`
+}
+
+type ReportStatsBucket struct {
+	Start    time.Time      `json:"start"`
+	Total    int            `json:"total"`
+	Statuses map[string]int `json:"statuses"`
+}
@@ -0,0 +1,22 @@
+package model
+
+// ShadowAgreement compares the classes predicted by the primary model with the
+// ones predicted by the shadow model on the same reports.
+type ShadowAgreement struct {
+	ModelVersion string            `json:"modelVersion"`
+	Total        int               `json:"total"`
+	ExactMatches int               `json:"exactMatches"`
+	Classes      []*ClassAgreement `json:"classes"`
+}
+
+// ClassAgreement counts, for a single class, the reports where both models,
+// only one of them, or neither of them found it. Agreement is the share of
+// reports where both models agree.
+type ClassAgreement struct {
+	Class       string  `json:"class"`
+	Both        int     `json:"both"`
+	PrimaryOnly int     `json:"primaryOnly"`
+	ShadowOnly  int     `json:"shadowOnly"`
+	Neither     int     `json:"neither"`
+	Agreement   float64 `json:"agreement"`
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/driver"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+)
+
+type PredictionBackfillRepository interface {
+	Create(ctx context.Context, e driver.Executor, backfill *entity.PredictionBackfill) (*entity.PredictionBackfill, error)
+	Get(ctx context.Context, e driver.Executor, backfillID int) (*entity.PredictionBackfill, error)
+	GetForUpdate(ctx context.Context, e driver.Executor, backfillID int) (*entity.PredictionBackfill, error)
+	GetAll(ctx context.Context, e driver.Executor) ([]*entity.PredictionBackfill, error)
+	GetAllByStatus(ctx context.Context, e driver.Executor, status string) ([]*entity.PredictionBackfill, error)
+	UpdateStatus(ctx context.Context, e driver.Executor, backfillID int, status string, lastError *string) (*entity.PredictionBackfill, error)
+	SetModelVersion(ctx context.Context, e driver.Executor, backfillID int, modelVersion string) error
+	Advance(ctx context.Context, e driver.Executor, backfillID, reportID int, result string, lastError *string) (string, error)
+	TryLock(ctx context.Context, e driver.Executor, backfillID int) (bool, error)
+	Unlock(ctx context.Context, e driver.Executor, backfillID int) error
+}
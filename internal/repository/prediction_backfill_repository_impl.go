@@ -0,0 +1,355 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/driver"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+)
+
+// backfillLockSpace namespaces the advisory locks taken on backfills so they
+// do not collide with other advisory locks on the same database.
+const backfillLockSpace = 1017
+
+const backfillColumns = `id, user_id, status, filter, rate, model_version, last_report_id,
+	processed, skipped, failed, last_error, created_at, updated_at, finished_at`
+
+type PredictionBackfillRepositoryImpl struct{}
+
+func NewPredictionBackfillRepository() PredictionBackfillRepository {
+	return &PredictionBackfillRepositoryImpl{}
+}
+
+func scanBackfill(row rowScanner) (*entity.PredictionBackfill, error) {
+	backfill := new(entity.PredictionBackfill)
+	var userID sql.NullInt32
+	var filter []byte
+	var modelVersion, lastError sql.NullString
+	var finishedAt sql.NullTime
+	if err := row.Scan(
+		&backfill.ID,
+		&userID,
+		&backfill.Status,
+		&filter,
+		&backfill.Rate,
+		&modelVersion,
+		&backfill.LastReportID,
+		&backfill.Processed,
+		&backfill.Skipped,
+		&backfill.Failed,
+		&lastError,
+		&backfill.CreatedAt,
+		&backfill.UpdatedAt,
+		&finishedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	backfill.Filter = filter
+	if userID.Valid {
+		id := int(userID.Int32)
+		backfill.UserID = &id
+	}
+	if modelVersion.Valid {
+		backfill.ModelVersion = &modelVersion.String
+	}
+	if lastError.Valid {
+		backfill.LastError = &lastError.String
+	}
+	if finishedAt.Valid {
+		backfill.FinishedAt = &finishedAt.Time
+	}
+
+	return backfill, nil
+}
+
+// runningBackfillConflict translates a violation of the index allowing a
+// single running backfill.
+func runningBackfillConflict(op string, err error) error {
+	if pgerr, ok := err.(*pgconn.PgError); ok && pgerr.ConstraintName == "prediction_backfills_running_idx" {
+		return api.NewSingleMessageException(
+			api.ECONFLICT,
+			op,
+			"Another backfill is already running",
+			errors.New("trying to run two backfills at once"),
+		)
+	}
+
+	return nil
+}
+
+func (r *PredictionBackfillRepositoryImpl) Create(
+	ctx context.Context,
+	e driver.Executor,
+	backfill *entity.PredictionBackfill,
+) (*entity.PredictionBackfill, error) {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	stmt := `INSERT INTO prediction_backfills (user_id, filter, rate)
+	VALUES ($1, $2, $3)
+	RETURNING ` + backfillColumns
+
+	const op = "PredictionBackfillRepositoryImpl.Create"
+	backfill, err := scanBackfill(e.QueryRowContext(ctx, stmt, backfill.UserID, backfill.Filter, backfill.Rate))
+	if err != nil {
+		if exc := runningBackfillConflict(op, err); exc != nil {
+			return nil, exc
+		}
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"r.Executor.QueryRowContext",
+			err,
+		)
+	}
+
+	return backfill, nil
+}
+
+func (r *PredictionBackfillRepositoryImpl) Get(ctx context.Context, e driver.Executor, backfillID int) (*entity.PredictionBackfill, error) {
+	return r.get(ctx, e, "PredictionBackfillRepositoryImpl.Get", backfillID, "")
+}
+
+func (r *PredictionBackfillRepositoryImpl) GetForUpdate(ctx context.Context, e driver.Executor, backfillID int) (*entity.PredictionBackfill, error) {
+	return r.get(ctx, e, "PredictionBackfillRepositoryImpl.GetForUpdate", backfillID, " FOR UPDATE")
+}
+
+func (r *PredictionBackfillRepositoryImpl) get(
+	ctx context.Context,
+	e driver.Executor,
+	op string,
+	backfillID int,
+	suffix string,
+) (*entity.PredictionBackfill, error) {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	stmt := `SELECT ` + backfillColumns + `
+	FROM prediction_backfills
+	WHERE id = $1` + suffix
+
+	backfill, err := scanBackfill(e.QueryRowContext(ctx, stmt, backfillID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, api.NewSingleMessageException(
+				api.ENOTFOUND,
+				op,
+				"Backfill Not Found",
+				err,
+			)
+		}
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"r.Executor.QueryRowContext",
+			err,
+		)
+	}
+
+	return backfill, nil
+}
+
+func (r *PredictionBackfillRepositoryImpl) GetAll(ctx context.Context, e driver.Executor) ([]*entity.PredictionBackfill, error) {
+	stmt := `SELECT ` + backfillColumns + `
+	FROM prediction_backfills
+	ORDER BY id DESC`
+
+	return r.getAll(ctx, e, "PredictionBackfillRepositoryImpl.GetAll", stmt)
+}
+
+func (r *PredictionBackfillRepositoryImpl) GetAllByStatus(
+	ctx context.Context,
+	e driver.Executor,
+	status string,
+) ([]*entity.PredictionBackfill, error) {
+	stmt := `SELECT ` + backfillColumns + `
+	FROM prediction_backfills
+	WHERE status = $1
+	ORDER BY id ASC`
+
+	return r.getAll(ctx, e, "PredictionBackfillRepositoryImpl.GetAllByStatus", stmt, status)
+}
+
+func (r *PredictionBackfillRepositoryImpl) getAll(
+	ctx context.Context,
+	e driver.Executor,
+	op string,
+	stmt string,
+	args ...interface{},
+) ([]*entity.PredictionBackfill, error) {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	rows, err := e.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"r.Executor.QueryContext",
+			err,
+		)
+	}
+
+	defer rows.Close()
+	backfills := []*entity.PredictionBackfill{}
+	for rows.Next() {
+		backfill, err := scanBackfill(rows)
+		if err != nil {
+			return nil, api.NewExceptionWithSourceLocation(
+				op,
+				"rows.Scan",
+				err,
+			)
+		}
+		backfills = append(backfills, backfill)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"rows.Err",
+			err,
+		)
+	}
+
+	return backfills, nil
+}
+
+// UpdateStatus moves a backfill to status. lastError is only overwritten when
+// it is not nil.
+func (r *PredictionBackfillRepositoryImpl) UpdateStatus(
+	ctx context.Context,
+	e driver.Executor,
+	backfillID int,
+	status string,
+	lastError *string,
+) (*entity.PredictionBackfill, error) {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	stmt := `UPDATE prediction_backfills
+	SET status = $1,
+	last_error = COALESCE($2, last_error),
+	updated_at = CURRENT_TIMESTAMP,
+	finished_at = CASE WHEN $1 = 'Completed' THEN CURRENT_TIMESTAMP END
+	WHERE id = $3
+	RETURNING ` + backfillColumns
+
+	const op = "PredictionBackfillRepositoryImpl.UpdateStatus"
+	backfill, err := scanBackfill(e.QueryRowContext(ctx, stmt, status, lastError, backfillID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, api.NewSingleMessageException(
+				api.ENOTFOUND,
+				op,
+				"Backfill Not Found",
+				err,
+			)
+		}
+		if exc := runningBackfillConflict(op, err); exc != nil {
+			return nil, exc
+		}
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"r.Executor.QueryRowContext",
+			err,
+		)
+	}
+
+	return backfill, nil
+}
+
+func (r *PredictionBackfillRepositoryImpl) SetModelVersion(
+	ctx context.Context,
+	e driver.Executor,
+	backfillID int,
+	modelVersion string,
+) error {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	stmt := `UPDATE prediction_backfills
+	SET model_version = $1, updated_at = CURRENT_TIMESTAMP
+	WHERE id = $2`
+
+	if _, err := e.ExecContext(ctx, stmt, modelVersion, backfillID); err != nil {
+		return api.NewExceptionWithSourceLocation(
+			"PredictionBackfillRepositoryImpl.SetModelVersion",
+			"r.Executor.ExecContext",
+			err,
+		)
+	}
+
+	return nil
+}
+
+// Advance records the result of reportID and moves the cursor past it. It
+// returns the current status of the backfill so that the runner notices when
+// the backfill was paused.
+func (r *PredictionBackfillRepositoryImpl) Advance(
+	ctx context.Context,
+	e driver.Executor,
+	backfillID,
+	reportID int,
+	result string,
+	lastError *string,
+) (string, error) {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	stmt := `UPDATE prediction_backfills
+	SET last_report_id = $1,
+	processed = processed + CASE WHEN $2 = 'processed' THEN 1 ELSE 0 END,
+	skipped = skipped + CASE WHEN $2 = 'skipped' THEN 1 ELSE 0 END,
+	failed = failed + CASE WHEN $2 = 'failed' THEN 1 ELSE 0 END,
+	last_error = COALESCE($3, last_error),
+	updated_at = CURRENT_TIMESTAMP
+	WHERE id = $4
+	RETURNING status`
+
+	var status string
+	if err := e.QueryRowContext(ctx, stmt, reportID, result, lastError, backfillID).Scan(&status); err != nil {
+		return "", api.NewExceptionWithSourceLocation(
+			"PredictionBackfillRepositoryImpl.Advance",
+			"r.Executor.QueryRowContext",
+			err,
+		)
+	}
+
+	return status, nil
+}
+
+// TryLock takes a session level advisory lock on the backfill, so e must be a
+// dedicated connection that is kept until Unlock.
+func (r *PredictionBackfillRepositoryImpl) TryLock(ctx context.Context, e driver.Executor, backfillID int) (bool, error) {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	var locked bool
+	if err := e.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1, $2)`, backfillLockSpace, backfillID).Scan(&locked); err != nil {
+		return false, api.NewExceptionWithSourceLocation(
+			"PredictionBackfillRepositoryImpl.TryLock",
+			"r.Executor.QueryRowContext",
+			err,
+		)
+	}
+
+	return locked, nil
+}
+
+func (r *PredictionBackfillRepositoryImpl) Unlock(ctx context.Context, e driver.Executor, backfillID int) error {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	if _, err := e.ExecContext(ctx, `SELECT pg_advisory_unlock($1, $2)`, backfillLockSpace, backfillID); err != nil {
+		return api.NewExceptionWithSourceLocation(
+			"PredictionBackfillRepositoryImpl.Unlock",
+			"r.Executor.ExecContext",
+			err,
+		)
+	}
+
+	return nil
+}
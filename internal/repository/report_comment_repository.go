@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/driver"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+)
+
+type ReportCommentRepository interface {
+	Create(ctx context.Context, e driver.Executor, comment *entity.ReportComment) (*entity.ReportComment, error)
+	GetAllByReportID(ctx context.Context, e driver.Executor, reportID int, includeInternal bool) ([]*entity.ReportComment, error)
+}
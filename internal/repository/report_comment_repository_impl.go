@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Masterminds/squirrel"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/driver"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+)
+
+type ReportCommentRepositoryImpl struct{}
+
+func NewReportCommentRepository() ReportCommentRepository {
+	return &ReportCommentRepositoryImpl{}
+}
+
+func (r *ReportCommentRepositoryImpl) Create(
+	ctx context.Context,
+	e driver.Executor,
+	comment *entity.ReportComment,
+) (*entity.ReportComment, error) {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	stmt := `WITH c AS (
+		INSERT INTO report_comments (report_id, user_id, body, internal)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, user_id, created_at
+	)
+	SELECT c.id, u.name, u.role, c.created_at
+	FROM c
+	JOIN users AS u ON u.id = c.user_id`
+
+	if err := e.QueryRowContext(
+		ctx,
+		stmt,
+		comment.ReportID,
+		comment.UserID,
+		comment.Body,
+		comment.Internal,
+	).Scan(
+		&comment.ID,
+		&comment.AuthorName,
+		&comment.AuthorRole,
+		&comment.CreatedAt,
+	); err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			"ReportCommentRepositoryImpl.Create",
+			"r.Executor.QueryRowContext",
+			err,
+		)
+	}
+
+	return comment, nil
+}
+
+func (r *ReportCommentRepositoryImpl) GetAllByReportID(
+	ctx context.Context,
+	e driver.Executor,
+	reportID int,
+	includeInternal bool,
+) ([]*entity.ReportComment, error) {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	const op = "ReportCommentRepositoryImpl.GetAllByReportID"
+	queryBuilder := squirrel.
+		Select("c.id", "c.report_id", "c.user_id", "u.name", "u.role", "c.body", "c.internal", "c.created_at").
+		From("report_comments AS c").
+		Join("users AS u ON u.id = c.user_id").
+		Where(squirrel.Eq{"c.report_id": reportID}).
+		OrderBy("c.created_at ASC", "c.id ASC").
+		PlaceholderFormat(squirrel.Dollar)
+	if !includeInternal {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"c.internal": false})
+	}
+
+	stmt, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"queryBuilder.ToSql",
+			err,
+		)
+	}
+
+	rows, err := e.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"r.Executor.QueryContext",
+			err,
+		)
+	}
+
+	defer rows.Close()
+	comments := []*entity.ReportComment{}
+	for rows.Next() {
+		comment := new(entity.ReportComment)
+		if err := rows.Scan(
+			&comment.ID,
+			&comment.ReportID,
+			&comment.UserID,
+			&comment.AuthorName,
+			&comment.AuthorRole,
+			&comment.Body,
+			&comment.Internal,
+			&comment.CreatedAt,
+		); err != nil {
+			return nil, api.NewExceptionWithSourceLocation(
+				op,
+				"rows.Scan",
+				err,
+			)
+		}
+		comments = append(comments, comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"rows.Err",
+			err,
+		)
+	}
+
+	return comments, nil
+}
@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/driver"
+)
+
+type ReportConfirmationRepository interface {
+	Create(ctx context.Context, e driver.Executor, reportID, userID int) error
+	Delete(ctx context.Context, e driver.Executor, reportID, userID int) error
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/driver"
+)
+
+type ReportConfirmationRepositoryImpl struct{}
+
+func NewReportConfirmationRepository() ReportConfirmationRepository {
+	return &ReportConfirmationRepositoryImpl{}
+}
+
+func (r *ReportConfirmationRepositoryImpl) Create(ctx context.Context, e driver.Executor, reportID, userID int) error {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	stmt := `INSERT INTO report_confirmations (report_id, user_id)
+	VALUES ($1, $2)`
+
+	const op = "ReportConfirmationRepositoryImpl.Create"
+	if _, err := e.ExecContext(ctx, stmt, reportID, userID); err != nil {
+		if pgerr, ok := err.(*pgconn.PgError); ok && pgerr.ConstraintName == "report_confirmations_pkey" {
+			return api.NewSingleMessageException(
+				api.ECONFLICT,
+				op,
+				"Report already confirmed",
+				errors.New("trying to confirm a report twice"),
+			)
+		}
+		return api.NewExceptionWithSourceLocation(
+			op,
+			"r.Executor.ExecContext",
+			err,
+		)
+	}
+
+	return nil
+}
+
+func (r *ReportConfirmationRepositoryImpl) Delete(ctx context.Context, e driver.Executor, reportID, userID int) error {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	stmt := `DELETE FROM report_confirmations
+	WHERE report_id = $1 AND user_id = $2`
+
+	const op = "ReportConfirmationRepositoryImpl.Delete"
+	res, err := e.ExecContext(ctx, stmt, reportID, userID)
+	if err != nil {
+		return api.NewExceptionWithSourceLocation(
+			op,
+			"r.Executor.ExecContext",
+			err,
+		)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return api.NewSingleMessageException(
+			api.ENOTFOUND,
+			op,
+			"Confirmation Not Found",
+			sql.ErrNoRows,
+		)
+	}
+
+	return nil
+}
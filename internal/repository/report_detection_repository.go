@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/driver"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+)
+
+type ReportDetectionRepository interface {
+	CreateAll(ctx context.Context, e driver.Executor, reportID int, detections []*entity.ReportDetection) ([]*entity.ReportDetection, error)
+	GetAllByReportID(ctx context.Context, e driver.Executor, reportID int) ([]*entity.ReportDetection, error)
+	DeleteBySource(ctx context.Context, e driver.Executor, reportID int, source string) error
+}
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/driver"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+)
+
+type ReportDetectionRepositoryImpl struct{}
+
+func NewReportDetectionRepository() ReportDetectionRepository {
+	return &ReportDetectionRepositoryImpl{}
+}
+
+func (r *ReportDetectionRepositoryImpl) CreateAll(
+	ctx context.Context,
+	e driver.Executor,
+	reportID int,
+	detections []*entity.ReportDetection,
+) ([]*entity.ReportDetection, error) {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	stmt := `INSERT INTO report_detections (report_id, class, score, x_min, y_min, x_max, y_max, source)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	RETURNING id`
+
+	for _, detection := range detections {
+		if err := e.QueryRowContext(
+			ctx,
+			stmt,
+			reportID,
+			detection.Class,
+			detection.Score,
+			detection.Box.XMin,
+			detection.Box.YMin,
+			detection.Box.XMax,
+			detection.Box.YMax,
+			detection.Source,
+		).Scan(&detection.ID); err != nil {
+			return nil, api.NewExceptionWithSourceLocation(
+				"ReportDetectionRepositoryImpl.CreateAll",
+				"r.Executor.QueryRowContext",
+				err,
+			)
+		}
+		detection.ReportID = reportID
+	}
+
+	return detections, nil
+}
+
+func (r *ReportDetectionRepositoryImpl) GetAllByReportID(
+	ctx context.Context,
+	e driver.Executor,
+	reportID int,
+) ([]*entity.ReportDetection, error) {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	stmt := `SELECT id, report_id, class, score, x_min, y_min, x_max, y_max, source
+	FROM report_detections
+	WHERE report_id = $1
+	ORDER BY score DESC, id ASC`
+
+	const op = "ReportDetectionRepositoryImpl.GetAllByReportID"
+	rows, err := e.QueryContext(ctx, stmt, reportID)
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"r.Executor.QueryContext",
+			err,
+		)
+	}
+
+	defer rows.Close()
+	detections := []*entity.ReportDetection{}
+	for rows.Next() {
+		detection := &entity.ReportDetection{
+			Box: new(entity.Box),
+		}
+		if err := rows.Scan(
+			&detection.ID,
+			&detection.ReportID,
+			&detection.Class,
+			&detection.Score,
+			&detection.Box.XMin,
+			&detection.Box.YMin,
+			&detection.Box.XMax,
+			&detection.Box.YMax,
+			&detection.Source,
+		); err != nil {
+			return nil, api.NewExceptionWithSourceLocation(
+				op,
+				"rows.Scan",
+				err,
+			)
+		}
+		detections = append(detections, detection)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"rows.Err",
+			err,
+		)
+	}
+
+	return detections, nil
+}
+
+func (r *ReportDetectionRepositoryImpl) DeleteBySource(ctx context.Context, e driver.Executor, reportID int, source string) error {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	stmt := `DELETE FROM report_detections
+	WHERE report_id = $1 AND source = $2`
+
+	if _, err := e.ExecContext(ctx, stmt, reportID, source); err != nil {
+		return api.NewExceptionWithSourceLocation(
+			"ReportDetectionRepositoryImpl.DeleteBySource",
+			"r.Executor.ExecContext",
+			err,
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"github.com/Masterminds/squirrel"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+)
+
+const (
+	facetStatus = "status"
+	facetClass  = "class"
+)
+
+const (
+	sortConfirmations = "confirmations"
+	sortScore         = "score"
+	orderAsc          = "asc"
+)
+
+const searchConfig = "indonesian"
+
+// statusNeedsReview marks reports that are kept out of listings until an admin
+// reviews their prediction.
+const statusNeedsReview = "Needs Review"
+
+func searchQuery(q string) squirrel.Sqlizer {
+	return squirrel.Expr("websearch_to_tsquery('"+searchConfig+"', ?)", q)
+}
+
+// reportOrder is a sort key computed per row. Listings sorted by it page
+// through results with a (key, id) keyset anchored on the last seen report.
+type reportOrder struct {
+	key   squirrel.Sqlizer
+	alias string
+	desc  bool
+}
+
+// reportListOrder picks how a filtered listing is sorted: by the requested
+// sort if any, by relevance when searching, by distance for geospatial queries
+// and by newest id otherwise, in which case it returns nil.
+func reportListOrder(filter *model.ReportFilter) *reportOrder {
+	if filter == nil {
+		return nil
+	}
+
+	switch filter.Sort {
+	case sortConfirmations:
+		return &reportOrder{
+			key:   squirrel.Expr(confirmationCount),
+			alias: "confirmations",
+			desc:  filter.Order != orderAsc,
+		}
+	case sortScore:
+		// Reports predicted before scores were stored rank as least confident.
+		return &reportOrder{
+			key:   squirrel.Expr("COALESCE(r.score, 0)"),
+			alias: "score",
+			desc:  filter.Order != orderAsc,
+		}
+	}
+
+	if filter.Query != "" {
+		return &reportOrder{
+			key: squirrel.Expr(
+				"ts_rank(r.search_vector, ?) + GREATEST(word_similarity(?, r.address), word_similarity(?, r.note))",
+				searchQuery(filter.Query),
+				filter.Query,
+				filter.Query,
+			),
+			alias: "relevance",
+			desc:  true,
+		}
+	}
+
+	if origin := filter.Geo.Origin(); origin != nil {
+		return &reportOrder{
+			key:   squirrel.Expr("ST_Distance(r.location, ?)", geographyPoint(origin)),
+			alias: "distance",
+		}
+	}
+
+	return nil
+}
+
+// reportFilterConditions translates a report filter into WHERE conditions on
+// the reports table aliased as r. The condition belonging to the facet named by
+// exclude is left out so that facet counts show every option, not just the
+// selected one.
+func reportFilterConditions(filter *model.ReportFilter, exclude string) squirrel.And {
+	conditions := squirrel.And{}
+	if filter == nil {
+		filter = &model.ReportFilter{}
+	}
+
+	if filter.Withdrawn {
+		conditions = append(conditions, squirrel.NotEq{"r.deleted_at": nil})
+	} else {
+		conditions = append(conditions, squirrel.Eq{"r.deleted_at": nil})
+	}
+
+	switch {
+	case filter.NeedsReview:
+		conditions = append(conditions, squirrel.Eq{"r.status": statusNeedsReview})
+	case !filter.IncludeNeedsReview:
+		conditions = append(conditions, squirrel.NotEq{"r.status": statusNeedsReview})
+	}
+
+	if len(filter.IDs) > 0 {
+		conditions = append(conditions, squirrel.Eq{"r.id": filter.IDs})
+	}
+
+	if filter.Relabeled {
+		conditions = append(conditions, squirrel.Eq{"r.detections_relabeled": true})
+	}
+
+	if !filter.IncludeDuplicates {
+		conditions = append(conditions, squirrel.Eq{"r.canonical_id": nil})
+	}
+
+	if len(filter.Statuses) > 0 && exclude != facetStatus {
+		conditions = append(conditions, squirrel.Eq{"r.status": filter.Statuses})
+	}
+
+	if len(filter.Classes) > 0 && exclude != facetClass {
+		conditions = append(conditions, squirrel.Expr("r.classes @> ?", filter.Classes))
+	}
+
+	if filter.Query != "" {
+		conditions = append(conditions, squirrel.Expr(
+			"(r.search_vector @@ ? OR ? <% r.address OR ? <% r.note)",
+			searchQuery(filter.Query),
+			filter.Query,
+			filter.Query,
+		))
+	}
+
+	if filter.MinScore != nil {
+		conditions = append(conditions, squirrel.GtOrEq{"r.score": *filter.MinScore})
+	}
+
+	if filter.MaxScore != nil {
+		conditions = append(conditions, squirrel.LtOrEq{"r.score": *filter.MaxScore})
+	}
+
+	if filter.From != nil {
+		conditions = append(conditions, squirrel.GtOrEq{"r.date_reported": *filter.From})
+	}
+
+	if filter.To != nil {
+		conditions = append(conditions, squirrel.Lt{"r.date_reported": *filter.To})
+	}
+
+	if filter.ReporterID > 0 {
+		conditions = append(conditions, squirrel.Eq{"r.user_id": filter.ReporterID})
+	}
+
+	if filter.Geo != nil {
+		if filter.Geo.Near != nil {
+			conditions = append(conditions, squirrel.Expr(
+				"ST_DWithin(r.location, ?, ?)",
+				geographyPoint(filter.Geo.Near),
+				filter.Geo.Radius,
+			))
+		}
+
+		if filter.Geo.BBox != nil {
+			conditions = append(conditions, squirrel.Expr(
+				"r.location && ST_MakeEnvelope(?, ?, ?, ?, 4326)::geography",
+				filter.Geo.BBox.MinLng,
+				filter.Geo.BBox.MinLat,
+				filter.Geo.BBox.MaxLng,
+				filter.Geo.BBox.MaxLat,
+			))
+		}
+	}
+
+	return conditions
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/driver"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+)
+
+type ReportPredictionRepository interface {
+	Create(ctx context.Context, e driver.Executor, prediction *entity.ReportPrediction) (bool, error)
+	Exists(ctx context.Context, e driver.Executor, reportID int, modelVersion string) (bool, error)
+	GetAllByReportID(ctx context.Context, e driver.Executor, reportID int) ([]*entity.ReportPrediction, error)
+	GetShadowAgreement(ctx context.Context, e driver.Executor, filter *model.ReportFilter, modelVersion string) (*model.ShadowAgreement, error)
+}
@@ -0,0 +1,295 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgtype"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/driver"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+)
+
+type ReportPredictionRepositoryImpl struct{}
+
+func NewReportPredictionRepository() ReportPredictionRepository {
+	return &ReportPredictionRepositoryImpl{}
+}
+
+// Create stores prediction unless the report already has one from the same
+// model version, in which case it returns false.
+func (r *ReportPredictionRepositoryImpl) Create(
+	ctx context.Context,
+	e driver.Executor,
+	prediction *entity.ReportPrediction,
+) (bool, error) {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	const op = "ReportPredictionRepositoryImpl.Create"
+	classScores, err := json.Marshal(prediction.ClassScores)
+	if err != nil {
+		return false, api.NewExceptionWithSourceLocation(
+			op,
+			"json.Marshal",
+			err,
+		)
+	}
+	detections, err := json.Marshal(prediction.Detections)
+	if err != nil {
+		return false, api.NewExceptionWithSourceLocation(
+			op,
+			"json.Marshal",
+			err,
+		)
+	}
+
+	stmt := `INSERT INTO report_predictions (report_id, backfill_id, model_version, shadow, classes, score, class_scores, detections)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	ON CONFLICT (report_id, model_version, shadow) DO NOTHING
+	RETURNING id, created_at`
+
+	if err := e.QueryRowContext(
+		ctx,
+		stmt,
+		prediction.ReportID,
+		prediction.BackfillID,
+		prediction.ModelVersion,
+		prediction.Shadow,
+		prediction.Classes,
+		prediction.Score,
+		classScores,
+		detections,
+	).Scan(
+		&prediction.ID,
+		&prediction.CreatedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, api.NewExceptionWithSourceLocation(
+			op,
+			"r.Executor.QueryRowContext",
+			err,
+		)
+	}
+
+	return true, nil
+}
+
+// Exists reports whether the report was already predicted by modelVersion,
+// either when it was submitted or afterwards. Shadow predictions do not
+// count, since a backfill of the candidate model stores its own.
+func (r *ReportPredictionRepositoryImpl) Exists(
+	ctx context.Context,
+	e driver.Executor,
+	reportID int,
+	modelVersion string,
+) (bool, error) {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	stmt := `SELECT EXISTS (
+		SELECT 1 FROM reports WHERE id = $1 AND model_version = $2
+		UNION ALL
+		SELECT 1 FROM report_predictions WHERE report_id = $1 AND model_version = $2 AND NOT shadow
+	)`
+
+	var exists bool
+	if err := e.QueryRowContext(ctx, stmt, reportID, modelVersion).Scan(&exists); err != nil {
+		return false, api.NewExceptionWithSourceLocation(
+			"ReportPredictionRepositoryImpl.Exists",
+			"r.Executor.QueryRowContext",
+			err,
+		)
+	}
+
+	return exists, nil
+}
+
+func (r *ReportPredictionRepositoryImpl) GetAllByReportID(
+	ctx context.Context,
+	e driver.Executor,
+	reportID int,
+) ([]*entity.ReportPrediction, error) {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	const op = "ReportPredictionRepositoryImpl.GetAllByReportID"
+	stmt := `SELECT id, report_id, backfill_id, model_version, shadow, classes, score, class_scores, detections, created_at
+	FROM report_predictions
+	WHERE report_id = $1
+	ORDER BY created_at ASC, id ASC`
+
+	rows, err := e.QueryContext(ctx, stmt, reportID)
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"r.Executor.QueryContext",
+			err,
+		)
+	}
+
+	defer rows.Close()
+	predictions := []*entity.ReportPrediction{}
+	for rows.Next() {
+		prediction := new(entity.ReportPrediction)
+		var backfillID sql.NullInt32
+		var cls pgtype.EnumArray
+		var score sql.NullFloat64
+		var classScores, detections []byte
+		if err := rows.Scan(
+			&prediction.ID,
+			&prediction.ReportID,
+			&backfillID,
+			&prediction.ModelVersion,
+			&prediction.Shadow,
+			&cls,
+			&score,
+			&classScores,
+			&detections,
+			&prediction.CreatedAt,
+		); err != nil {
+			return nil, api.NewExceptionWithSourceLocation(
+				op,
+				"rows.Scan",
+				err,
+			)
+		}
+
+		prediction.Classes = enumArrayToStrings(cls)
+		if backfillID.Valid {
+			id := int(backfillID.Int32)
+			prediction.BackfillID = &id
+		}
+		if score.Valid {
+			prediction.Score = &score.Float64
+		}
+		if classScores != nil {
+			if err := json.Unmarshal(classScores, &prediction.ClassScores); err != nil {
+				return nil, api.NewExceptionWithSourceLocation(
+					op,
+					"json.Unmarshal",
+					err,
+				)
+			}
+		}
+		if detections != nil {
+			if err := json.Unmarshal(detections, &prediction.Detections); err != nil {
+				return nil, api.NewExceptionWithSourceLocation(
+					op,
+					"json.Unmarshal",
+					err,
+				)
+			}
+		}
+		predictions = append(predictions, prediction)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"rows.Err",
+			err,
+		)
+	}
+
+	return predictions, nil
+}
+
+// GetShadowAgreement compares, for every class, the original prediction of the
+// reports matching filter with their shadow prediction by modelVersion. The classes predicted
+// by the primary model are used rather than the reports' current classes, which
+// admins may have corrected.
+func (r *ReportPredictionRepositoryImpl) GetShadowAgreement(
+	ctx context.Context,
+	e driver.Executor,
+	filter *model.ReportFilter,
+	modelVersion string,
+) (*model.ShadowAgreement, error) {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	const op = "ReportPredictionRepositoryImpl.GetShadowAgreement"
+	conditions := squirrel.And{
+		reportFilterConditions(filter, ""),
+		squirrel.Eq{"p.shadow": true, "p.model_version": modelVersion},
+	}
+
+	stmt, args, err := squirrel.
+		Select(
+			"c::text",
+			"COUNT(*) FILTER (WHERE c = ANY(r.predicted_classes) AND c = ANY(p.classes))",
+			"COUNT(*) FILTER (WHERE c = ANY(r.predicted_classes) AND NOT c = ANY(p.classes))",
+			"COUNT(*) FILTER (WHERE NOT c = ANY(r.predicted_classes) AND c = ANY(p.classes))",
+			"COUNT(*) FILTER (WHERE NOT c = ANY(r.predicted_classes) AND NOT c = ANY(p.classes))",
+			"COUNT(*)",
+			"COUNT(*) FILTER (WHERE r.predicted_classes @> p.classes AND p.classes @> r.predicted_classes)",
+		).
+		From("report_predictions AS p").
+		Join("reports AS r ON r.id = p.report_id").
+		CrossJoin("unnest(enum_range(NULL::class)) AS c").
+		Where(conditions).
+		GroupBy("c").
+		OrderBy("c").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"queryBuilder.ToSql",
+			err,
+		)
+	}
+
+	rows, err := e.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"r.Executor.QueryContext",
+			err,
+		)
+	}
+
+	defer rows.Close()
+	agreement := &model.ShadowAgreement{
+		ModelVersion: modelVersion,
+		Classes:      []*model.ClassAgreement{},
+	}
+	for rows.Next() {
+		class := new(model.ClassAgreement)
+		if err := rows.Scan(
+			&class.Class,
+			&class.Both,
+			&class.PrimaryOnly,
+			&class.ShadowOnly,
+			&class.Neither,
+			&agreement.Total,
+			&agreement.ExactMatches,
+		); err != nil {
+			return nil, api.NewExceptionWithSourceLocation(
+				op,
+				"rows.Scan",
+				err,
+			)
+		}
+
+		if agreement.Total > 0 {
+			class.Agreement = float64(class.Both+class.Neither) / float64(agreement.Total)
+		}
+		agreement.Classes = append(agreement.Classes, class)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"rows.Err",
+			err,
+		)
+	}
+
+	return agreement, nil
+}
@@ -10,7 +10,24 @@ import (
 
 type ReportRepository interface {
 	Create(ctx context.Context, e driver.Executor, userID int, report *entity.Report) (*entity.Report, error)
-	GetAll(ctx context.Context, e driver.Executor, pagination *model.Pagination) ([]*entity.Report, error)
-	GetAllByUserID(ctx context.Context, e driver.Executor, userID int, pagination *model.Pagination) ([]*entity.Report, error)
+	Get(ctx context.Context, e driver.Executor, reportID int) (*entity.Report, error)
+	GetStatusForUpdate(ctx context.Context, e driver.Executor, reportID int) (string, error)
+	GetAll(ctx context.Context, e driver.Executor, pagination *model.Pagination, filter *model.ReportFilter) ([]*entity.Report, error)
+	GetAllByUserID(ctx context.Context, e driver.Executor, userID int, pagination *model.Pagination, filter *model.ReportFilter) ([]*entity.Report, error)
+	Stream(ctx context.Context, e driver.Executor, filter *model.ReportFilter, fn func(*entity.Report) error) error
+	GetFacets(ctx context.Context, e driver.Executor, filter *model.ReportFilter) (*model.ReportFacets, error)
+	GetClusters(ctx context.Context, e driver.Executor, filter *model.ReportFilter, cellSize float64) ([]*model.ReportCluster, error)
+	GetTile(ctx context.Context, e driver.Executor, z, x, y int, filter *model.ReportFilter) ([]byte, error)
+	GetStats(ctx context.Context, e driver.Executor, filter *model.ReportFilter, interval string) (*model.ReportStats, error)
 	Update(ctx context.Context, e driver.Executor, status string, reportID int) (*entity.Report, error)
+	LockArea(ctx context.Context, e driver.Executor, location *entity.Location, radius float64) error
+	FindDuplicate(ctx context.Context, e driver.Executor, location *entity.Location, classes []string, radius float64) (int, error)
+	SetCanonical(ctx context.Context, e driver.Executor, reportID int, canonicalID *int) error
+	ReassignDuplicates(ctx context.Context, e driver.Executor, fromID, toID int) error
+	ReleaseDuplicates(ctx context.Context, e driver.Executor, reportID int) error
+	UpdateDetails(ctx context.Context, e driver.Executor, report *entity.Report) error
+	SetDeleted(ctx context.Context, e driver.Executor, reportID int, deleted bool) error
+	CreateClassScores(ctx context.Context, e driver.Executor, reportID int, classScores map[string]float64) error
+	Relabel(ctx context.Context, e driver.Executor, reportID int, classes []string, detectionsRelabeled bool, userID int) error
+	GetIDs(ctx context.Context, e driver.Executor, filter *model.ReportFilter, afterID int, limit uint64) ([]int, error)
 }
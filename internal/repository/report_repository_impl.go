@@ -3,6 +3,11 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"strings"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/jackc/pgtype"
@@ -14,6 +19,121 @@ import (
 
 type ReportRepositoryImpl struct{}
 
+var reportColumns = []string{
+	"r.id",
+	"r.user_id",
+	"u.name",
+	"r.status",
+	"r.image_url",
+	"r.classes",
+	"r.note",
+	"r.address",
+	"ST_Y(r.location::geometry)",
+	"ST_X(r.location::geometry)",
+	"r.date_reported",
+	"r.canonical_id",
+	"(SELECT COUNT(*) FROM reports AS d WHERE d.canonical_id = r.id)",
+	confirmationCount,
+	"r.deleted_at",
+	"r.score",
+	"(SELECT json_object_agg(s.class, s.score) FROM report_class_scores AS s WHERE s.report_id = r.id)",
+	"r.predicted_classes",
+	"r.relabeled_at",
+	"r.detections_relabeled",
+	"r.model_version",
+	"r.review_reason",
+}
+
+const confirmationCount = "(SELECT COUNT(*) FROM report_confirmations AS c WHERE c.report_id = r.id)"
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanReport scans a row selected with reportColumns followed by the extra
+// columns, if any.
+func scanReport(row rowScanner, extra ...interface{}) (*entity.Report, error) {
+	report := new(entity.Report)
+	location := new(entity.Location)
+	var cls pgtype.EnumArray
+	var canonicalID sql.NullInt32
+	var deletedAt sql.NullTime
+	var score sql.NullFloat64
+	var classScores []byte
+	var predictedCls pgtype.EnumArray
+	var relabeledAt sql.NullTime
+	var modelVersion sql.NullString
+	var reviewReason sql.NullString
+	dest := append([]interface{}{
+		&report.ID,
+		&report.UserID,
+		&report.ReporterName,
+		&report.Status,
+		&report.ImageURL,
+		&cls,
+		&report.Note,
+		&report.Address,
+		&location.Lat,
+		&location.Lng,
+		&report.DateReported,
+		&canonicalID,
+		&report.DuplicateCount,
+		&report.ConfirmationCount,
+		&deletedAt,
+		&score,
+		&classScores,
+		&predictedCls,
+		&relabeledAt,
+		&report.DetectionsRelabeled,
+		&modelVersion,
+		&reviewReason,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	report.Classes = enumArrayToStrings(cls)
+	report.PredictedClasses = enumArrayToStrings(predictedCls)
+	report.Location = location
+	if relabeledAt.Valid {
+		report.RelabeledAt = &relabeledAt.Time
+	}
+	report.ReviewReason = reviewReason.String
+	if modelVersion.Valid {
+		report.ModelVersion = &modelVersion.String
+	}
+	if canonicalID.Valid {
+		id := int(canonicalID.Int32)
+		report.CanonicalID = &id
+	}
+	if deletedAt.Valid {
+		report.DeletedAt = &deletedAt.Time
+	}
+	if score.Valid {
+		report.Score = &score.Float64
+	}
+	if classScores != nil {
+		if err := json.Unmarshal(classScores, &report.ClassScores); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+func enumArrayToStrings(cls pgtype.EnumArray) []string {
+	classes := make([]string, len(cls.Elements))
+	for k, v := range cls.Elements {
+		classes[k] = v.String
+	}
+
+	return classes
+}
+
+func geographyPoint(location *entity.Location) squirrel.Sqlizer {
+	return squirrel.Expr("ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography", location.Lng, location.Lat)
+}
+
 func NewReportRepository() ReportRepository {
 	return &ReportRepositoryImpl{}
 }
@@ -27,9 +147,10 @@ func (r *ReportRepositoryImpl) Create(
 	ctx, cancel := newDBContext(ctx)
 	defer cancel()
 
-	stmt := `INSERT INTO reports (image_url, classes, note, address, lat, lng, user_id)
-	VALUES ($1, $2, $3, $4, $5, $6, $7)
-	RETURNING id, status, image_url, classes, note, address, lat, lng, date_reported`
+	stmt := `INSERT INTO reports (image_url, classes, predicted_classes, note, address, location, user_id, canonical_id, score, model_version, status, review_reason)
+	VALUES ($1, $2, $2, $3, $4, ST_SetSRID(ST_MakePoint($5, $6), 4326)::geography, $7, $8, $9, $10, COALESCE(NULLIF($11, '')::status, 'Reported'), NULLIF($12, ''))
+	RETURNING id, status, image_url, classes, note, address,
+	ST_Y(location::geometry), ST_X(location::geometry), date_reported`
 
 	var cls pgtype.EnumArray
 	if err := e.QueryRowContext(
@@ -39,9 +160,14 @@ func (r *ReportRepositoryImpl) Create(
 		report.Classes,
 		report.Note,
 		report.Address,
-		report.Location.Lat,
 		report.Location.Lng,
+		report.Location.Lat,
 		report.UserID,
+		report.CanonicalID,
+		report.Score,
+		report.ModelVersion,
+		report.Status,
+		report.ReviewReason,
 	).Scan(
 		&report.ID,
 		&report.Status,
@@ -59,32 +185,171 @@ func (r *ReportRepositoryImpl) Create(
 			err,
 		)
 	}
-	classes := make([]string, len(cls.Elements))
-	for k, v := range cls.Elements {
-		classes[k] = v.String
+	report.Classes = enumArrayToStrings(cls)
+
+	return report, nil
+}
+
+func (r *ReportRepositoryImpl) Get(ctx context.Context, e driver.Executor, reportID int) (*entity.Report, error) {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	const op = "ReportRepositoryImpl.Get"
+	stmt, args, err := squirrel.
+		Select(reportColumns...).
+		From("users AS u").Join("reports AS r ON u.id = r.user_id").PlaceholderFormat(squirrel.Dollar).
+		Where(squirrel.Eq{"r.id": reportID}).
+		ToSql()
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"queryBuilder.ToSql",
+			err,
+		)
+	}
+
+	report, err := scanReport(e.QueryRowContext(ctx, stmt, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, api.NewSingleMessageException(
+				api.ENOTFOUND,
+				op,
+				"Report Not Found",
+				err,
+			)
+		}
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"r.Executor.QueryRowContext",
+			err,
+		)
 	}
-	report.Classes = classes
 
 	return report, nil
 }
 
-func (r *ReportRepositoryImpl) GetAll(ctx context.Context, e driver.Executor, pagination *model.Pagination) ([]*entity.Report, error) {
+func (r *ReportRepositoryImpl) GetStatusForUpdate(ctx context.Context, e driver.Executor, reportID int) (string, error) {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	stmt := `SELECT status FROM reports
+	WHERE id = $1
+	FOR UPDATE`
+
+	const op = "ReportRepositoryImpl.GetStatusForUpdate"
+	var status string
+	if err := e.QueryRowContext(ctx, stmt, reportID).Scan(&status); err != nil {
+		if err == sql.ErrNoRows {
+			return "", api.NewSingleMessageException(
+				api.ENOTFOUND,
+				op,
+				"Report Not Found",
+				err,
+			)
+		}
+		return "", api.NewExceptionWithSourceLocation(
+			op,
+			"r.Executor.QueryRowContext",
+			err,
+		)
+	}
+
+	return status, nil
+}
+
+func (r *ReportRepositoryImpl) GetAll(
+	ctx context.Context,
+	e driver.Executor,
+	pagination *model.Pagination,
+	filter *model.ReportFilter,
+) ([]*entity.Report, error) {
+	return r.getAll(ctx, e, "ReportRepositoryImpl.GetAll", pagination, filter)
+}
+
+func (r *ReportRepositoryImpl) GetAllByUserID(
+	ctx context.Context,
+	e driver.Executor,
+	userID int,
+	pagination *model.Pagination,
+	filter *model.ReportFilter,
+) ([]*entity.Report, error) {
+	userFilter := model.ReportFilter{}
+	if filter != nil {
+		userFilter = *filter
+	}
+	userFilter.ReporterID = userID
+	// Reporters always see their own submissions, including duplicates.
+	userFilter.IncludeDuplicates = true
+	userFilter.IncludeNeedsReview = true
+
+	return r.getAll(ctx, e, "ReportRepositoryImpl.GetAllByUserID", pagination, &userFilter)
+}
+
+func (r *ReportRepositoryImpl) getAll(
+	ctx context.Context,
+	e driver.Executor,
+	op string,
+	pagination *model.Pagination,
+	filter *model.ReportFilter,
+) ([]*entity.Report, error) {
 	ctx, cancel := newDBContext(ctx)
 	defer cancel()
 
-	const op = "ReportRepositoryImpl.GetAll"
 	queryBuilder := squirrel.
-		Select("r.id", "name", "status", "image_url", "classes", "note", "address", "lat", "lng", "date_reported").
-		From("users AS u").Join("reports AS r ON u.id = r.user_id").PlaceholderFormat(squirrel.Dollar).OrderBy("r.id DESC")
+		Select(reportColumns...).
+		From("users AS u").Join("reports AS r ON u.id = r.user_id").PlaceholderFormat(squirrel.Dollar).
+		Where(reportFilterConditions(filter, ""))
 
-	if pagination.Limit > 0 {
-		queryBuilder = queryBuilder.Limit(pagination.Limit)
+	order := reportListOrder(filter)
+	if order != nil {
+		direction, comparison := "ASC", ">"
+		if order.desc {
+			direction, comparison = "DESC", "<"
+		}
+		queryBuilder = queryBuilder.
+			Column(squirrel.Alias(order.key, order.alias)).
+			OrderBy(order.alias+" "+direction, "r.id "+direction)
+
+		if pagination.LastseenID > 0 {
+			// The keyset is anchored on the key of the last seen report, so
+			// an unknown one would compare against NULL and match nothing.
+			var exists bool
+			if err := e.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM reports WHERE id = $1)", pagination.LastseenID).Scan(&exists); err != nil {
+				return nil, api.NewExceptionWithSourceLocation(
+					op,
+					"r.Executor.QueryRowContext",
+					err,
+				)
+			}
+			if !exists {
+				return nil, api.NewSingleMessageException(
+					api.EINVALID,
+					op,
+					"Invalid lastseenid argument",
+					errors.New("last seen report does not exist"),
+				)
+			}
+
+			queryBuilder = queryBuilder.Where(squirrel.Expr(
+				"(?, r.id) "+comparison+" ((SELECT ? FROM reports AS r WHERE r.id = ?), ?)",
+				order.key,
+				order.key,
+				pagination.LastseenID,
+				pagination.LastseenID,
+			))
+		}
+	} else {
+		queryBuilder = queryBuilder.OrderBy("r.id DESC")
+
+		if pagination.LastseenID > 0 {
+			queryBuilder = queryBuilder.Where(squirrel.Lt{
+				"r.id": pagination.LastseenID,
+			})
+		}
 	}
 
-	if pagination.LastseenID > 0 {
-		queryBuilder = queryBuilder.Where(squirrel.Lt{
-			"r.id": pagination.LastseenID,
-		})
+	if pagination.Limit > 0 {
+		queryBuilder = queryBuilder.Limit(pagination.Limit)
 	}
 
 	stmt, args, err := queryBuilder.ToSql()
@@ -108,66 +373,226 @@ func (r *ReportRepositoryImpl) GetAll(ctx context.Context, e driver.Executor, pa
 	defer rows.Close()
 	reports := []*entity.Report{}
 	for rows.Next() {
-		report := new(entity.Report)
-		location := new(entity.Location)
-		var cls pgtype.EnumArray
-		if err := rows.Scan(
-			&report.ID,
-			&report.ReporterName,
-			&report.Status,
-			&report.ImageURL,
-			&cls,
-			&report.Note,
-			&report.Address,
-			&location.Lat,
-			&location.Lng,
-			&report.DateReported,
-		); err != nil {
+		var extra []interface{}
+		var key *float64
+		if order != nil {
+			key = new(float64)
+			extra = append(extra, key)
+		}
+		report, err := scanReport(rows, extra...)
+		if err != nil {
 			return nil, api.NewExceptionWithSourceLocation(
 				op,
 				"rows.Scan",
 				err,
 			)
 		}
-		classes := make([]string, len(cls.Elements))
-		for k, v := range cls.Elements {
-			classes[k] = v.String
+		if order != nil {
+			switch order.alias {
+			case "relevance":
+				report.Relevance = key
+			case "distance":
+				report.Distance = key
+			}
 		}
-		report.Classes = classes
-		report.Location = location
 		reports = append(reports, report)
 	}
 
 	return reports, nil
 }
 
-func (r *ReportRepositoryImpl) GetAllByUserID(ctx context.Context, e driver.Executor, userID int, pagination *model.Pagination) ([]*entity.Report, error) {
+// Stream calls fn for every report matching filter, oldest first, while the
+// rows are being read. It stops at the first error returned by fn.
+func (r *ReportRepositoryImpl) Stream(
+	ctx context.Context,
+	e driver.Executor,
+	filter *model.ReportFilter,
+	fn func(*entity.Report) error,
+) error {
+	ctx, cancel := newDBStreamContext(ctx)
+	defer cancel()
+
+	const op = "ReportRepositoryImpl.Stream"
+	stmt, args, err := squirrel.
+		Select(reportColumns...).
+		From("users AS u").Join("reports AS r ON u.id = r.user_id").PlaceholderFormat(squirrel.Dollar).
+		Where(reportFilterConditions(filter, "")).
+		OrderBy("r.id ASC").
+		ToSql()
+	if err != nil {
+		return api.NewExceptionWithSourceLocation(
+			op,
+			"queryBuilder.ToSql",
+			err,
+		)
+	}
+
+	rows, err := e.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return api.NewExceptionWithSourceLocation(
+			op,
+			"r.Executor.QueryContext",
+			err,
+		)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return api.NewExceptionWithSourceLocation(
+				op,
+				"rows.Scan",
+				err,
+			)
+		}
+
+		if err := fn(report); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return api.NewExceptionWithSourceLocation(
+			op,
+			"rows.Err",
+			err,
+		)
+	}
+
+	return nil
+}
+
+func (r *ReportRepositoryImpl) GetFacets(ctx context.Context, e driver.Executor, filter *model.ReportFilter) (*model.ReportFacets, error) {
 	ctx, cancel := newDBContext(ctx)
 	defer cancel()
 
-	const op = "ReportRepositoryImpl.GetAll"
-	queryBuilder := squirrel.
-		Select("r.id", "name", "status", "image_url", "classes", "note", "address", "lat", "lng", "date_reported").
-		From("users AS u").Join("reports AS r ON u.id = r.user_id").PlaceholderFormat(squirrel.Dollar).OrderBy("r.id DESC").
-		Where(squirrel.Eq{
-			"r.user_id": userID,
-		})
+	const op = "ReportRepositoryImpl.GetFacets"
+	total := squirrel.
+		Select("'total'", "''", "COUNT(*)").
+		From("reports AS r").
+		Where(reportFilterConditions(filter, ""))
+	byStatus := squirrel.
+		Select("'status'", "r.status::text", "COUNT(*)").
+		From("reports AS r").
+		Where(reportFilterConditions(filter, facetStatus)).
+		GroupBy("r.status")
+	byClass := squirrel.
+		Select("'class'", "c::text", "COUNT(DISTINCT r.id)").
+		From("reports AS r").
+		CrossJoin("LATERAL unnest(r.classes) AS c").
+		Where(reportFilterConditions(filter, facetClass)).
+		GroupBy("c")
 
-	if pagination.Limit > 0 {
-		queryBuilder = queryBuilder.Limit(pagination.Limit)
+	var stmts []string
+	var args []interface{}
+	for _, queryBuilder := range []squirrel.SelectBuilder{total, byStatus, byClass} {
+		stmt, queryArgs, err := queryBuilder.ToSql()
+		if err != nil {
+			return nil, api.NewExceptionWithSourceLocation(
+				op,
+				"queryBuilder.ToSql",
+				err,
+			)
+		}
+		stmts = append(stmts, stmt)
+		args = append(args, queryArgs...)
 	}
 
-	if pagination.LastseenID > 0 {
-		queryBuilder = queryBuilder.Where(squirrel.Lt{
-			"r.id": pagination.LastseenID,
-		})
+	stmt, err := squirrel.Dollar.ReplacePlaceholders(strings.Join(stmts, " UNION ALL "))
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"squirrel.Dollar.ReplacePlaceholders",
+			err,
+		)
 	}
 
-	stmt, args, err := queryBuilder.ToSql()
+	rows, err := e.QueryContext(ctx, stmt, args...)
 	if err != nil {
 		return nil, api.NewExceptionWithSourceLocation(
 			op,
-			"queryBuilder.ToSql",
+			"r.Executor.QueryContext",
+			err,
+		)
+	}
+
+	defer rows.Close()
+	facets := &model.ReportFacets{
+		Statuses: map[string]int{},
+		Classes:  map[string]int{},
+	}
+	for rows.Next() {
+		var facet, value string
+		var count int
+		if err := rows.Scan(&facet, &value, &count); err != nil {
+			return nil, api.NewExceptionWithSourceLocation(
+				op,
+				"rows.Scan",
+				err,
+			)
+		}
+
+		switch facet {
+		case "total":
+			facets.Total = count
+		case facetStatus:
+			facets.Statuses[value] = count
+		case facetClass:
+			facets.Classes[value] = count
+		}
+	}
+
+	return facets, nil
+}
+
+func (r *ReportRepositoryImpl) GetClusters(
+	ctx context.Context,
+	e driver.Executor,
+	filter *model.ReportFilter,
+	cellSize float64,
+) ([]*model.ReportCluster, error) {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	const op = "ReportRepositoryImpl.GetClusters"
+	cells := squirrel.
+		Select("r.id", "r.classes", "r.location::geometry AS geom").
+		Column("floor(ST_X(r.location::geometry) / ?)::bigint AS cx", cellSize).
+		Column("floor(ST_Y(r.location::geometry) / ?)::bigint AS cy", cellSize).
+		From("reports AS r").
+		Where(reportFilterConditions(filter, ""))
+
+	cellsStmt, args, err := cells.ToSql()
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"cells.ToSql",
+			err,
+		)
+	}
+
+	stmt, err := squirrel.Dollar.ReplacePlaceholders(`WITH cells AS (` + cellsStmt + `),
+	clusters AS (
+		SELECT cx, cy, COUNT(*) AS total, MIN(id) AS report_id, ST_Centroid(ST_Collect(geom)) AS center
+		FROM cells
+		GROUP BY cx, cy
+	),
+	dominant AS (
+		SELECT DISTINCT ON (cx, cy) cx, cy, c::text AS class
+		FROM cells
+		CROSS JOIN LATERAL unnest(classes) AS c
+		GROUP BY cx, cy, c
+		ORDER BY cx, cy, COUNT(*) DESC, c
+	)
+	SELECT total, report_id, ST_Y(center), ST_X(center), COALESCE(class, '')
+	FROM clusters
+	LEFT JOIN dominant USING (cx, cy)
+	ORDER BY total DESC`)
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"squirrel.Dollar.ReplacePlaceholders",
 			err,
 		)
 	}
@@ -182,22 +607,17 @@ func (r *ReportRepositoryImpl) GetAllByUserID(ctx context.Context, e driver.Exec
 	}
 
 	defer rows.Close()
-	reports := []*entity.Report{}
+	clusters := []*model.ReportCluster{}
 	for rows.Next() {
-		report := new(entity.Report)
+		cluster := new(model.ReportCluster)
 		location := new(entity.Location)
-		var cls pgtype.EnumArray
+		var reportID int
 		if err := rows.Scan(
-			&report.ID,
-			&report.ReporterName,
-			&report.Status,
-			&report.ImageURL,
-			&cls,
-			&report.Note,
-			&report.Address,
+			&cluster.Count,
+			&reportID,
 			&location.Lat,
 			&location.Lng,
-			&report.DateReported,
+			&cluster.DominantClass,
 		); err != nil {
 			return nil, api.NewExceptionWithSourceLocation(
 				op,
@@ -205,16 +625,178 @@ func (r *ReportRepositoryImpl) GetAllByUserID(ctx context.Context, e driver.Exec
 				err,
 			)
 		}
-		classes := make([]string, len(cls.Elements))
-		for k, v := range cls.Elements {
-			classes[k] = v.String
+		if cluster.Count == 1 {
+			cluster.ReportID = &reportID
 		}
-		report.Classes = classes
-		report.Location = location
-		reports = append(reports, report)
+		cluster.Location = location
+		clusters = append(clusters, cluster)
 	}
 
-	return reports, nil
+	return clusters, nil
+}
+
+func (r *ReportRepositoryImpl) GetTile(
+	ctx context.Context,
+	e driver.Executor,
+	z, x, y int,
+	filter *model.ReportFilter,
+) ([]byte, error) {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	const op = "ReportRepositoryImpl.GetTile"
+	features := squirrel.
+		Select(
+			"ST_AsMVTGeom(ST_Transform(r.location::geometry, 3857), t.envelope) AS geom",
+			"r.id",
+			"r.status::text AS status",
+			"COALESCE(r.classes[1]::text, '') AS class",
+			"array_to_string(r.classes, ',') AS classes",
+		).
+		From("reports AS r").
+		// Tiles are compared in Web Mercator, like reports_location_3857_idx,
+		// since a tile envelope cast to geography wraps around the
+		// antimeridian and bends its edges at low zooms.
+		Join("tile AS t ON ST_Transform(r.location::geometry, 3857) && t.envelope").
+		Where(reportFilterConditions(filter, ""))
+
+	featuresStmt, args, err := features.ToSql()
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"features.ToSql",
+			err,
+		)
+	}
+
+	stmt, err := squirrel.Dollar.ReplacePlaceholders(`WITH tile AS (
+		SELECT ST_TileEnvelope(?, ?, ?) AS envelope
+	),
+	features AS (` + featuresStmt + `)
+	SELECT ST_AsMVT(features.*, 'reports', 4096, 'geom') FROM features`)
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"squirrel.Dollar.ReplacePlaceholders",
+			err,
+		)
+	}
+
+	var tile []byte
+	if err := e.QueryRowContext(ctx, stmt, append([]interface{}{z, x, y}, args...)...).Scan(&tile); err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"r.Executor.QueryRowContext",
+			err,
+		)
+	}
+
+	return tile, nil
+}
+
+func (r *ReportRepositoryImpl) GetStats(
+	ctx context.Context,
+	e driver.Executor,
+	filter *model.ReportFilter,
+	interval string,
+) (*model.ReportStats, error) {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	const op = "ReportRepositoryImpl.GetStats"
+	conditions := reportFilterConditions(filter, "")
+	byStatus := squirrel.
+		Select("'status'", "r.status::text", "NULL::timestamp", "COUNT(*)").
+		From("reports AS r").
+		Where(conditions).
+		GroupBy("r.status")
+	byClass := squirrel.
+		Select("'class'", "c::text", "NULL::timestamp", "COUNT(DISTINCT r.id)").
+		From("reports AS r").
+		CrossJoin("LATERAL unnest(r.classes) AS c").
+		Where(conditions).
+		GroupBy("c")
+	byBucket := squirrel.
+		Select("'bucket'", "r.status::text").
+		Column("date_trunc(?, r.date_reported)", interval).
+		Column("COUNT(*)").
+		From("reports AS r").
+		Where(conditions).
+		GroupBy("2", "3")
+
+	var stmts []string
+	var args []interface{}
+	for _, queryBuilder := range []squirrel.SelectBuilder{byStatus, byClass, byBucket} {
+		stmt, queryArgs, err := queryBuilder.ToSql()
+		if err != nil {
+			return nil, api.NewExceptionWithSourceLocation(
+				op,
+				"queryBuilder.ToSql",
+				err,
+			)
+		}
+		stmts = append(stmts, stmt)
+		args = append(args, queryArgs...)
+	}
+
+	stmt, err := squirrel.Dollar.ReplacePlaceholders(strings.Join(stmts, " UNION ALL ") + " ORDER BY 1, 3")
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"squirrel.Dollar.ReplacePlaceholders",
+			err,
+		)
+	}
+
+	rows, err := e.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"r.Executor.QueryContext",
+			err,
+		)
+	}
+
+	defer rows.Close()
+	stats := &model.ReportStats{
+		Statuses: map[string]int{},
+		Classes:  map[string]int{},
+		Interval: interval,
+		Series:   []*model.ReportStatsBucket{},
+	}
+	var bucket *model.ReportStatsBucket
+	for rows.Next() {
+		var group, value string
+		var start sql.NullTime
+		var count int
+		if err := rows.Scan(&group, &value, &start, &count); err != nil {
+			return nil, api.NewExceptionWithSourceLocation(
+				op,
+				"rows.Scan",
+				err,
+			)
+		}
+
+		switch group {
+		case "status":
+			stats.Statuses[value] = count
+			stats.Total += count
+		case "class":
+			stats.Classes[value] = count
+		case "bucket":
+			if bucket == nil || !bucket.Start.Equal(start.Time) {
+				bucket = &model.ReportStatsBucket{
+					Start:    start.Time,
+					Statuses: map[string]int{},
+				}
+				stats.Series = append(stats.Series, bucket)
+			}
+			bucket.Statuses[value] = count
+			bucket.Total += count
+		}
+	}
+
+	return stats, nil
 }
 
 func (r *ReportRepositoryImpl) Update(ctx context.Context, e driver.Executor, status string, reportID int) (*entity.Report, error) {
@@ -224,7 +806,8 @@ func (r *ReportRepositoryImpl) Update(ctx context.Context, e driver.Executor, st
 	stmt := `UPDATE reports
 	SET status = $1
 	WHERE id = $2
-	RETURNING id, status, image_url, classes, note, address, lat, lng, date_reported`
+	RETURNING id, status, image_url, classes, note, address,
+	ST_Y(location::geometry), ST_X(location::geometry), date_reported`
 
 	report := new(entity.Report)
 	location := new(entity.Location)
@@ -254,12 +837,371 @@ func (r *ReportRepositoryImpl) Update(ctx context.Context, e driver.Executor, st
 			err,
 		)
 	}
-	classes := make([]string, len(cls.Elements))
-	for k, v := range cls.Elements {
-		classes[k] = v.String
-	}
-	report.Classes = classes
+	report.Classes = enumArrayToStrings(cls)
 	report.Location = location
 
 	return report, nil
 }
+
+// FindDuplicate returns the id of the closest canonical report within radius
+// meters of location that shares at least one class and is still being worked
+// on, or 0 if there is none.
+func (r *ReportRepositoryImpl) FindDuplicate(
+	ctx context.Context,
+	e driver.Executor,
+	location *entity.Location,
+	classes []string,
+	radius float64,
+) (int, error) {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	const op = "ReportRepositoryImpl.FindDuplicate"
+	stmt, args, err := squirrel.
+		Select("r.id").
+		From("reports AS r").
+		Where(squirrel.Eq{"r.canonical_id": nil, "r.deleted_at": nil}).
+		Where(squirrel.NotEq{"r.status": []string{"Completed", "Rejected", statusNeedsReview}}).
+		Where(squirrel.Expr("r.classes && ?::class[]", classes)).
+		Where(squirrel.Expr("ST_DWithin(r.location, ?, ?)", geographyPoint(location), radius)).
+		OrderByClause(squirrel.Expr("ST_Distance(r.location, ?)", geographyPoint(location))).
+		OrderBy("r.id").
+		Limit(1).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return 0, api.NewExceptionWithSourceLocation(
+			op,
+			"queryBuilder.ToSql",
+			err,
+		)
+	}
+
+	var id int
+	if err := e.QueryRowContext(ctx, stmt, args...).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, api.NewExceptionWithSourceLocation(
+			op,
+			"r.Executor.QueryRowContext",
+			err,
+		)
+	}
+
+	return id, nil
+}
+
+// duplicateLockSpace namespaces the advisory locks taken while looking for
+// duplicates so they do not collide with other advisory locks.
+const duplicateLockSpace = 1013
+
+// metersPerDegree is the length of a degree of latitude, rounded down so that
+// cells err on the large side.
+const metersPerDegree = 110000
+
+// LockArea takes transaction level advisory locks on a grid of cells as large
+// as radius, covering every cell within radius of location. Two submissions
+// within radius of each other always share a cell, so they look for
+// duplicates one after the other. Cells are hashed into the lock key, which
+// at worst serializes submissions that are far apart.
+func (r *ReportRepositoryImpl) LockArea(ctx context.Context, e driver.Executor, location *entity.Location, radius float64) error {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	cell := radius / metersPerDegree
+	// Degrees of longitude shrink towards the poles, so the area is as wide
+	// as it needs to be on its side farthest from the equator.
+	lngRadius := cell / math.Max(math.Cos((math.Abs(location.Lat)+cell)*math.Pi/180), 0.01)
+	minX, maxX := math.Floor((location.Lng-lngRadius)/cell), math.Floor((location.Lng+lngRadius)/cell)
+	minY, maxY := math.Floor((location.Lat-cell)/cell), math.Floor((location.Lat+cell)/cell)
+
+	keys := map[int32]bool{}
+	for x := minX; x <= maxX; x++ {
+		for y := minY; y <= maxY; y++ {
+			keys[int32(int64(x)*73856093^int64(y)*19349663)] = true
+		}
+	}
+	// Locks are always taken in the same order so that submissions cannot
+	// deadlock each other.
+	sorted := make([]int, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, int(key))
+	}
+	sort.Ints(sorted)
+
+	for _, key := range sorted {
+		if _, err := e.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, $2)`, duplicateLockSpace, key); err != nil {
+			return api.NewExceptionWithSourceLocation(
+				"ReportRepositoryImpl.LockArea",
+				"r.Executor.ExecContext",
+				err,
+			)
+		}
+	}
+
+	return nil
+}
+
+func (r *ReportRepositoryImpl) SetCanonical(ctx context.Context, e driver.Executor, reportID int, canonicalID *int) error {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	stmt := `UPDATE reports
+	SET canonical_id = $1
+	WHERE id = $2`
+
+	const op = "ReportRepositoryImpl.SetCanonical"
+	res, err := e.ExecContext(ctx, stmt, canonicalID, reportID)
+	if err != nil {
+		return api.NewExceptionWithSourceLocation(
+			op,
+			"r.Executor.ExecContext",
+			err,
+		)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return api.NewSingleMessageException(
+			api.ENOTFOUND,
+			op,
+			"Report Not Found",
+			sql.ErrNoRows,
+		)
+	}
+
+	return nil
+}
+
+// ReassignDuplicates moves every duplicate of fromID over to toID so that
+// duplicates never point at a report that is itself a duplicate.
+func (r *ReportRepositoryImpl) ReassignDuplicates(ctx context.Context, e driver.Executor, fromID, toID int) error {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	stmt := `UPDATE reports
+	SET canonical_id = $1
+	WHERE canonical_id = $2`
+
+	if _, err := e.ExecContext(ctx, stmt, toID, fromID); err != nil {
+		return api.NewExceptionWithSourceLocation(
+			"ReportRepositoryImpl.ReassignDuplicates",
+			"r.Executor.ExecContext",
+			err,
+		)
+	}
+
+	return nil
+}
+
+// ReleaseDuplicates turns every duplicate of reportID into a canonical report
+// of its own.
+func (r *ReportRepositoryImpl) ReleaseDuplicates(ctx context.Context, e driver.Executor, reportID int) error {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	stmt := `UPDATE reports
+	SET canonical_id = NULL
+	WHERE canonical_id = $1`
+
+	if _, err := e.ExecContext(ctx, stmt, reportID); err != nil {
+		return api.NewExceptionWithSourceLocation(
+			"ReportRepositoryImpl.ReleaseDuplicates",
+			"r.Executor.ExecContext",
+			err,
+		)
+	}
+
+	return nil
+}
+
+// UpdateDetails saves the note, address and location of report.
+func (r *ReportRepositoryImpl) UpdateDetails(ctx context.Context, e driver.Executor, report *entity.Report) error {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	stmt := `UPDATE reports
+	SET note = $1, address = $2, location = ST_SetSRID(ST_MakePoint($3, $4), 4326)::geography
+	WHERE id = $5`
+
+	if _, err := e.ExecContext(
+		ctx,
+		stmt,
+		report.Note,
+		report.Address,
+		report.Location.Lng,
+		report.Location.Lat,
+		report.ID,
+	); err != nil {
+		return api.NewExceptionWithSourceLocation(
+			"ReportRepositoryImpl.UpdateDetails",
+			"r.Executor.ExecContext",
+			err,
+		)
+	}
+
+	return nil
+}
+
+// SetDeleted soft deletes a report, or restores it when deleted is false.
+func (r *ReportRepositoryImpl) SetDeleted(ctx context.Context, e driver.Executor, reportID int, deleted bool) error {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	stmt := `UPDATE reports
+	SET deleted_at = CASE WHEN $1 THEN CURRENT_TIMESTAMP END
+	WHERE id = $2`
+
+	if _, err := e.ExecContext(ctx, stmt, deleted, reportID); err != nil {
+		return api.NewExceptionWithSourceLocation(
+			"ReportRepositoryImpl.SetDeleted",
+			"r.Executor.ExecContext",
+			err,
+		)
+	}
+
+	return nil
+}
+
+// CreateClassScores stores the confidence the model has in each class it
+// detected on a report.
+func (r *ReportRepositoryImpl) CreateClassScores(
+	ctx context.Context,
+	e driver.Executor,
+	reportID int,
+	classScores map[string]float64,
+) error {
+	if len(classScores) == 0 {
+		return nil
+	}
+
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	classes := make([]string, 0, len(classScores))
+	for class := range classScores {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	queryBuilder := squirrel.
+		Insert("report_class_scores").
+		Columns("report_id", "class", "score").
+		PlaceholderFormat(squirrel.Dollar)
+	for _, class := range classes {
+		queryBuilder = queryBuilder.Values(reportID, class, classScores[class])
+	}
+
+	const op = "ReportRepositoryImpl.CreateClassScores"
+	stmt, args, err := queryBuilder.ToSql()
+	if err != nil {
+		return api.NewExceptionWithSourceLocation(
+			op,
+			"queryBuilder.ToSql",
+			err,
+		)
+	}
+
+	if _, err := e.ExecContext(ctx, stmt, args...); err != nil {
+		return api.NewExceptionWithSourceLocation(
+			op,
+			"r.Executor.ExecContext",
+			err,
+		)
+	}
+
+	return nil
+}
+
+// Relabel replaces the classes of a report with the ones given by an admin.
+// The predicted classes are left untouched.
+func (r *ReportRepositoryImpl) Relabel(
+	ctx context.Context,
+	e driver.Executor,
+	reportID int,
+	classes []string,
+	detectionsRelabeled bool,
+	userID int,
+) error {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	stmt := `UPDATE reports
+	SET classes = $1,
+	detections_relabeled = detections_relabeled OR $2,
+	relabeled_by = $3,
+	relabeled_at = CURRENT_TIMESTAMP
+	WHERE id = $4`
+
+	if _, err := e.ExecContext(ctx, stmt, classes, detectionsRelabeled, userID, reportID); err != nil {
+		return api.NewExceptionWithSourceLocation(
+			"ReportRepositoryImpl.Relabel",
+			"r.Executor.ExecContext",
+			err,
+		)
+	}
+
+	return nil
+}
+
+// GetIDs returns the ids of up to limit reports matching filter that come
+// after afterID, in ascending order.
+func (r *ReportRepositoryImpl) GetIDs(
+	ctx context.Context,
+	e driver.Executor,
+	filter *model.ReportFilter,
+	afterID int,
+	limit uint64,
+) ([]int, error) {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	const op = "ReportRepositoryImpl.GetIDs"
+	stmt, args, err := squirrel.
+		Select("r.id").
+		From("reports AS r").PlaceholderFormat(squirrel.Dollar).
+		Where(reportFilterConditions(filter, "")).
+		Where(squirrel.Gt{"r.id": afterID}).
+		OrderBy("r.id ASC").
+		Limit(limit).
+		ToSql()
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"queryBuilder.ToSql",
+			err,
+		)
+	}
+
+	rows, err := e.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"r.Executor.QueryContext",
+			err,
+		)
+	}
+
+	defer rows.Close()
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, api.NewExceptionWithSourceLocation(
+				op,
+				"rows.Scan",
+				err,
+			)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"rows.Err",
+			err,
+		)
+	}
+
+	return ids, nil
+}
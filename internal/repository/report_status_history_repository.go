@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/driver"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+)
+
+type ReportStatusHistoryRepository interface {
+	Create(ctx context.Context, e driver.Executor, history *entity.ReportStatusHistory) (*entity.ReportStatusHistory, error)
+	GetAllByReportID(ctx context.Context, e driver.Executor, reportID int) ([]*entity.ReportStatusHistory, error)
+}
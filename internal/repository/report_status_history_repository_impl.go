@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/driver"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+)
+
+type ReportStatusHistoryRepositoryImpl struct{}
+
+func NewReportStatusHistoryRepository() ReportStatusHistoryRepository {
+	return &ReportStatusHistoryRepositoryImpl{}
+}
+
+func (r *ReportStatusHistoryRepositoryImpl) Create(
+	ctx context.Context,
+	e driver.Executor,
+	history *entity.ReportStatusHistory,
+) (*entity.ReportStatusHistory, error) {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	stmt := `INSERT INTO report_status_history (report_id, user_id, old_status, new_status, note)
+	VALUES ($1, $2, $3, $4, $5)
+	RETURNING id, created_at`
+
+	if err := e.QueryRowContext(
+		ctx,
+		stmt,
+		history.ReportID,
+		history.UserID,
+		history.OldStatus,
+		history.NewStatus,
+		history.Note,
+	).Scan(
+		&history.ID,
+		&history.CreatedAt,
+	); err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			"ReportStatusHistoryRepositoryImpl.Create",
+			"r.Executor.QueryRowContext",
+			err,
+		)
+	}
+
+	return history, nil
+}
+
+func (r *ReportStatusHistoryRepositoryImpl) GetAllByReportID(
+	ctx context.Context,
+	e driver.Executor,
+	reportID int,
+) ([]*entity.ReportStatusHistory, error) {
+	ctx, cancel := newDBContext(ctx)
+	defer cancel()
+
+	stmt := `SELECT h.id, h.report_id, h.user_id, COALESCE(u.name, ''), h.old_status, h.new_status, h.note, h.created_at
+	FROM report_status_history AS h
+	LEFT JOIN users AS u ON u.id = h.user_id
+	WHERE h.report_id = $1
+	ORDER BY h.created_at ASC, h.id ASC`
+
+	const op = "ReportStatusHistoryRepositoryImpl.GetAllByReportID"
+	rows, err := e.QueryContext(ctx, stmt, reportID)
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"r.Executor.QueryContext",
+			err,
+		)
+	}
+
+	defer rows.Close()
+	timeline := []*entity.ReportStatusHistory{}
+	for rows.Next() {
+		history := new(entity.ReportStatusHistory)
+		if err := rows.Scan(
+			&history.ID,
+			&history.ReportID,
+			&history.UserID,
+			&history.ActorName,
+			&history.OldStatus,
+			&history.NewStatus,
+			&history.Note,
+			&history.CreatedAt,
+		); err != nil {
+			return nil, api.NewExceptionWithSourceLocation(
+				op,
+				"rows.Scan",
+				err,
+			)
+		}
+		timeline = append(timeline, history)
+	}
+
+	return timeline, nil
+}
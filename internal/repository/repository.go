@@ -8,3 +8,9 @@ import (
 func newDBContext(ctx context.Context) (context.Context, context.CancelFunc) {
 	return context.WithTimeout(ctx, 3*time.Second)
 }
+
+// newDBStreamContext is used by queries whose rows are consumed while they
+// are being read, such as exports.
+func newDBStreamContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, 10*time.Minute)
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// newPredictor creates the predictor named by kind, which is "http" when
+// empty. target is the URL of an HTTP predict service or the address of a
+// gRPC one, and is ignored by the fake predictor.
+func newPredictor(kind, target string) (service.Predictor, error) {
+	switch kind {
+	case "", "http":
+		return service.NewHTTPPredictor(target), nil
+	case "grpc":
+		creds := credentials.NewTLS(&tls.Config{})
+		if os.Getenv("PREDICT_GRPC_INSECURE") == "true" {
+			creds = insecure.NewCredentials()
+		}
+		conn, err := grpc.Dial(target, grpc.WithTransportCredentials(creds))
+		if err != nil {
+			return nil, err
+		}
+
+		return service.NewGRPCPredictor(conn), nil
+	case "fake":
+		var rules []*service.FakePredictRule
+		if rulesPath := os.Getenv("FAKE_PREDICT_RULES"); rulesPath != "" {
+			f, err := os.Open(rulesPath)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+
+			rules, err = service.LoadFakePredictRules(f)
+			if err != nil {
+				return nil, fmt.Errorf("invalid FAKE_PREDICT_RULES: %w", err)
+			}
+		}
+
+		return service.NewFakePredictor(nil, rules...), nil
+	default:
+		return nil, fmt.Errorf("unknown predictor %q, expecting http, grpc or fake", kind)
+	}
+}
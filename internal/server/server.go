@@ -1,12 +1,14 @@
 package server
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"log"
 	"net/http"
 	"os"
 	"runtime"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	mid "github.com/go-chi/chi/v5/middleware"
@@ -23,6 +25,16 @@ import (
 	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/validation"
 )
 
+// defaultDuplicateRadius is used when DUPLICATE_RADIUS is not set.
+const defaultDuplicateRadius float64 = 25
+
+// Defaults used when PREDICT_MAX_CONCURRENCY and PREDICT_MAX_ATTEMPTS are not
+// set.
+const (
+	defaultPredictMaxConcurrency = 8
+	defaultPredictMaxAttempts    = 3
+)
+
 type App struct {
 	*chi.Mux
 	*sql.DB
@@ -58,11 +70,83 @@ func New() *App {
 	userHandler := handler.NewUserHandler(v, userSVC)
 	userHandler.Route(r)
 
-	predictAPIURL := os.Getenv("PREDICT_API_URL")
+	basePredictor, err := newPredictor(os.Getenv("PREDICTOR"), os.Getenv("PREDICT_API_URL"))
+	if err != nil {
+		log.Fatalf("Invalid PREDICTOR: %s", err)
+	}
+	maxConcurrency := defaultPredictMaxConcurrency
+	if concurrencyStr := os.Getenv("PREDICT_MAX_CONCURRENCY"); concurrencyStr != "" {
+		maxConcurrency, err = strconv.Atoi(concurrencyStr)
+		if err != nil || maxConcurrency < 1 {
+			log.Fatalf("Invalid PREDICT_MAX_CONCURRENCY: %s", concurrencyStr)
+		}
+	}
+	maxAttempts := defaultPredictMaxAttempts
+	if attemptsStr := os.Getenv("PREDICT_MAX_ATTEMPTS"); attemptsStr != "" {
+		maxAttempts, err = strconv.Atoi(attemptsStr)
+		if err != nil || maxAttempts < 1 {
+			log.Fatalf("Invalid PREDICT_MAX_ATTEMPTS: %s", attemptsStr)
+		}
+	}
+	predictor := service.NewResilientPredictor(basePredictor, maxConcurrency, maxAttempts)
+	healthHandler := handler.NewHealthHandler(predictor)
+	healthHandler.Route(r)
+	var shadowPredictor service.Predictor
+	if shadowPredictAPIURL := os.Getenv("SHADOW_PREDICT_API_URL"); shadowPredictAPIURL != "" {
+		shadowKind := os.Getenv("SHADOW_PREDICTOR")
+		if shadowKind == "" {
+			shadowKind = os.Getenv("PREDICTOR")
+		}
+		shadowPredictor, err = newPredictor(shadowKind, shadowPredictAPIURL)
+		if err != nil {
+			log.Fatalf("Invalid SHADOW_PREDICTOR: %s", err)
+		}
+	}
 	reportRepo := repository.NewReportRepository()
-	reportSRV := service.NewReportService(configApp, reportRepo, userRepo, predictAPIURL)
+	historyRepo := repository.NewReportStatusHistoryRepository()
+	confirmationRepo := repository.NewReportConfirmationRepository()
+	detectionRepo := repository.NewReportDetectionRepository()
+	predictionRepo := repository.NewReportPredictionRepository()
+	duplicateRadius := defaultDuplicateRadius
+	if radiusStr := os.Getenv("DUPLICATE_RADIUS"); radiusStr != "" {
+		radius, err := strconv.ParseFloat(radiusStr, 64)
+		if err != nil {
+			log.Fatalf("Invalid DUPLICATE_RADIUS: %s", err)
+		}
+		duplicateRadius = radius
+	}
+	var reviewThresholds *service.ReviewThresholds
+	thresholdStr, classThresholdsStr := os.Getenv("REVIEW_THRESHOLD"), os.Getenv("REVIEW_CLASS_THRESHOLDS")
+	if thresholdStr != "" || classThresholdsStr != "" {
+		reviewThresholds = &service.ReviewThresholds{}
+		if thresholdStr != "" {
+			threshold, err := strconv.ParseFloat(thresholdStr, 64)
+			if err != nil {
+				log.Fatalf("Invalid REVIEW_THRESHOLD: %s", err)
+			}
+			reviewThresholds.Default = threshold
+		}
+		reviewThresholds.Classes, err = service.ParseClassThresholds(classThresholdsStr)
+		if err != nil {
+			log.Fatalf("Invalid REVIEW_CLASS_THRESHOLDS: %s", err)
+		}
+	}
+	reportSRV := service.NewReportService(configApp, reportRepo, userRepo, historyRepo, confirmationRepo, detectionRepo, predictionRepo, predictor, shadowPredictor, duplicateRadius, reviewThresholds)
 	reportHandler := handler.NewReportHandler(v, reportSRV)
 	reportHandler.Route(r)
+	tileHandler := handler.NewTileHandler(v, reportSRV)
+	tileHandler.Route(r)
+	commentRepo := repository.NewReportCommentRepository()
+	commentSRV := service.NewReportCommentService(configApp, reportRepo, commentRepo)
+	commentHandler := handler.NewReportCommentHandler(v, commentSRV)
+	commentHandler.Route(r)
+	backfillRepo := repository.NewPredictionBackfillRepository()
+	backfillSRV := service.NewPredictionBackfillService(configApp, reportRepo, predictionRepo, backfillRepo, predictor, service.NewHTTPImageFetcher(http.DefaultClient))
+	backfillHandler := handler.NewPredictionBackfillHandler(v, backfillSRV)
+	backfillHandler.Route(r)
+	if err := backfillSRV.ResumeRunning(context.Background()); err != nil {
+		logger.Error(op, &model.SourceLocation{Function: "backfillSRV.ResumeRunning"}, err)
+	}
 
 	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
 		err := api.NewSingleMessageException(
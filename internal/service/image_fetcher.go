@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
+)
+
+const (
+	fetchImageTimeout = 10 * time.Second
+	maxImageSize      = 20 << 20
+)
+
+// ImageFetcher downloads report photos from storage.
+type ImageFetcher interface {
+	Fetch(ctx context.Context, imageURL string) ([]byte, error)
+}
+
+// HTTPImageFetcher downloads report photos from their public URL.
+type HTTPImageFetcher struct {
+	Client *http.Client
+}
+
+func NewHTTPImageFetcher(client *http.Client) *HTTPImageFetcher {
+	return &HTTPImageFetcher{
+		Client: client,
+	}
+}
+
+func (f *HTTPImageFetcher) Fetch(ctx context.Context, imageURL string) ([]byte, error) {
+	const op = "HTTPImageFetcher.Fetch"
+	timeoutCTX, cancel := context.WithTimeout(ctx, fetchImageTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(timeoutCTX, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"http.NewRequestWithContext",
+			err,
+		)
+	}
+	res, err := f.Client.Do(req)
+	if err != nil {
+		return nil, api.NewSingleMessageException(
+			api.EUNAVAILABLE,
+			op,
+			"Report image is unavailable. Please Try Again",
+			err,
+		)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, api.NewSingleMessageException(
+			api.EUNAVAILABLE,
+			op,
+			"Report image is unavailable. Please Try Again",
+			fmt.Errorf("image storage returned %s", res.Status),
+		)
+	}
+
+	image, err := ioutil.ReadAll(io.LimitReader(res.Body, maxImageSize))
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"ioutil.ReadAll",
+			err,
+		)
+	}
+
+	return image, nil
+}
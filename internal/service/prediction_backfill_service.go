@@ -0,0 +1,23 @@
+package service
+
+import (
+	"context"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+)
+
+type PredictionBackfillService interface {
+	Create(
+		ctx context.Context,
+		userID int,
+		filter *model.ReportFilter,
+		createPredictionBackfillDTO *model.CreatePredictionBackfillDTO,
+	) (*entity.PredictionBackfill, error)
+	Get(ctx context.Context, backfillID int) (*entity.PredictionBackfill, error)
+	GetAll(ctx context.Context) ([]*entity.PredictionBackfill, error)
+	Pause(ctx context.Context, backfillID int) (*entity.PredictionBackfill, error)
+	Resume(ctx context.Context, backfillID int) (*entity.PredictionBackfill, error)
+	ResumeRunning(ctx context.Context) error
+	GetPredictions(ctx context.Context, reportID int) ([]*entity.ReportPrediction, error)
+}
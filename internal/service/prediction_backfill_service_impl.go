@@ -0,0 +1,381 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"path"
+	"time"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/config"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/driver"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/logger"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/repository"
+)
+
+const (
+	defaultBackfillRate = 1
+	backfillBatchSize   = 100
+	// Backfills stored before their rate was validated are clamped to these
+	// intervals, since a ticker panics on a non-positive one.
+	minBackfillInterval = 100 * time.Millisecond
+	maxBackfillInterval = 100 * time.Second
+)
+
+type PredictionBackfillServiceImpl struct {
+	*config.App
+	ReportRepository             repository.ReportRepository
+	ReportPredictionRepository   repository.ReportPredictionRepository
+	PredictionBackfillRepository repository.PredictionBackfillRepository
+	Predictor                    Predictor
+	ImageFetcher                 ImageFetcher
+}
+
+func NewPredictionBackfillService(
+	app *config.App,
+	reportRepo repository.ReportRepository,
+	predictionRepo repository.ReportPredictionRepository,
+	backfillRepo repository.PredictionBackfillRepository,
+	predictor Predictor,
+	imageFetcher ImageFetcher) PredictionBackfillService {
+	return &PredictionBackfillServiceImpl{
+		App:                          app,
+		ReportRepository:             reportRepo,
+		ReportPredictionRepository:   predictionRepo,
+		PredictionBackfillRepository: backfillRepo,
+		Predictor:                    predictor,
+		ImageFetcher:                 imageFetcher,
+	}
+}
+
+// Create starts re-running prediction on the reports matching filter. Only one
+// backfill runs at a time.
+func (s *PredictionBackfillServiceImpl) Create(
+	ctx context.Context,
+	userID int,
+	filter *model.ReportFilter,
+	createPredictionBackfillDTO *model.CreatePredictionBackfillDTO) (*entity.PredictionBackfill, error) {
+	const op = "PredictionBackfillServiceImpl.Create"
+	backfillFilter := model.ReportFilter{}
+	if filter != nil {
+		backfillFilter = *filter
+	}
+	backfillFilter.IDs = createPredictionBackfillDTO.ReportIDs
+	// Every report has its own photo, duplicates included.
+	backfillFilter.IncludeDuplicates = true
+	encodedFilter, err := json.Marshal(backfillFilter)
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"json.Marshal",
+			err,
+		)
+	}
+
+	rate := createPredictionBackfillDTO.Rate
+	if rate == 0 {
+		rate = defaultBackfillRate
+	}
+
+	backfill, err := s.PredictionBackfillRepository.Create(ctx, s.App.DB, &entity.PredictionBackfill{
+		UserID: &userID,
+		Filter: encodedFilter,
+		Rate:   rate,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.start(backfill.ID)
+
+	return backfill, nil
+}
+
+func (s *PredictionBackfillServiceImpl) Get(ctx context.Context, backfillID int) (*entity.PredictionBackfill, error) {
+	return s.PredictionBackfillRepository.Get(ctx, s.App.DB, backfillID)
+}
+
+func (s *PredictionBackfillServiceImpl) GetAll(ctx context.Context) ([]*entity.PredictionBackfill, error) {
+	return s.PredictionBackfillRepository.GetAll(ctx, s.App.DB)
+}
+
+// Pause stops a running backfill after the report it is working on.
+func (s *PredictionBackfillServiceImpl) Pause(ctx context.Context, backfillID int) (*entity.PredictionBackfill, error) {
+	return s.transition(ctx, "PredictionBackfillServiceImpl.Pause", backfillID, entity.BackfillStatusRunning, entity.BackfillStatusPaused)
+}
+
+// Resume continues a paused backfill from the report after the last one it
+// handled.
+func (s *PredictionBackfillServiceImpl) Resume(ctx context.Context, backfillID int) (*entity.PredictionBackfill, error) {
+	backfill, err := s.transition(ctx, "PredictionBackfillServiceImpl.Resume", backfillID, entity.BackfillStatusPaused, entity.BackfillStatusRunning)
+	if err != nil {
+		return nil, err
+	}
+	s.start(backfill.ID)
+
+	return backfill, nil
+}
+
+func (s *PredictionBackfillServiceImpl) transition(
+	ctx context.Context,
+	op string,
+	backfillID int,
+	from,
+	to string) (*entity.PredictionBackfill, error) {
+	var backfill *entity.PredictionBackfill
+	if err := driver.WithTransaction(s.App.DB, func(e driver.Executor) error {
+		current, err := s.PredictionBackfillRepository.GetForUpdate(ctx, e, backfillID)
+		if err != nil {
+			return err
+		}
+
+		if current.Status != from {
+			return api.NewSingleMessageException(
+				api.ECONFLICT,
+				op,
+				"Backfill is "+current.Status,
+				errors.New("invalid backfill status transition"),
+			)
+		}
+
+		backfill, err = s.PredictionBackfillRepository.UpdateStatus(ctx, e, backfillID, to, nil)
+
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return backfill, nil
+}
+
+// ResumeRunning restarts the backfills that were running when the server
+// stopped.
+func (s *PredictionBackfillServiceImpl) ResumeRunning(ctx context.Context) error {
+	backfills, err := s.PredictionBackfillRepository.GetAllByStatus(ctx, s.App.DB, entity.BackfillStatusRunning)
+	if err != nil {
+		return err
+	}
+
+	for _, backfill := range backfills {
+		s.start(backfill.ID)
+	}
+
+	return nil
+}
+
+func (s *PredictionBackfillServiceImpl) GetPredictions(ctx context.Context, reportID int) ([]*entity.ReportPrediction, error) {
+	if _, err := s.ReportRepository.Get(ctx, s.App.DB, reportID); err != nil {
+		return nil, err
+	}
+
+	return s.ReportPredictionRepository.GetAllByReportID(ctx, s.App.DB, reportID)
+}
+
+func (s *PredictionBackfillServiceImpl) start(backfillID int) {
+	go func() {
+		const op = "PredictionBackfillServiceImpl.run"
+		if err := s.run(context.Background(), backfillID); err != nil {
+			logger.Error(op, &model.SourceLocation{Function: "s.run"}, err)
+		}
+	}()
+}
+
+// run works through a backfill until it completes, is paused, or the predict
+// service becomes unavailable. An advisory lock keeps other instances of the
+// server from running the same backfill.
+func (s *PredictionBackfillServiceImpl) run(ctx context.Context, backfillID int) error {
+	const op = "PredictionBackfillServiceImpl.run"
+	conn, err := s.App.DB.Conn(ctx)
+	if err != nil {
+		return api.NewExceptionWithSourceLocation(
+			op,
+			"s.App.DB.Conn",
+			err,
+		)
+	}
+	defer conn.Close()
+
+	locked, err := s.PredictionBackfillRepository.TryLock(ctx, conn, backfillID)
+	if err != nil || !locked {
+		return err
+	}
+	defer s.PredictionBackfillRepository.Unlock(ctx, conn, backfillID)
+
+	backfill, err := s.PredictionBackfillRepository.Get(ctx, s.App.DB, backfillID)
+	if err != nil {
+		return err
+	}
+	if backfill.Status != entity.BackfillStatusRunning {
+		return nil
+	}
+
+	filter := new(model.ReportFilter)
+	if err := json.Unmarshal(backfill.Filter, filter); err != nil {
+		return api.NewExceptionWithSourceLocation(
+			op,
+			"json.Unmarshal",
+			err,
+		)
+	}
+
+	limiter := time.NewTicker(backfillInterval(backfill.Rate))
+	defer limiter.Stop()
+
+	for {
+		reportIDs, err := s.ReportRepository.GetIDs(ctx, s.App.DB, filter, backfill.LastReportID, backfillBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(reportIDs) == 0 {
+			_, err := s.PredictionBackfillRepository.UpdateStatus(ctx, s.App.DB, backfillID, entity.BackfillStatusCompleted, nil)
+			return err
+		}
+
+		for _, reportID := range reportIDs {
+			status, err := s.backfillReport(ctx, backfill, reportID, limiter.C)
+			if err != nil {
+				message := err.Error()
+				if _, err := s.PredictionBackfillRepository.UpdateStatus(ctx, s.App.DB, backfillID, entity.BackfillStatusPaused, &message); err != nil {
+					return err
+				}
+				return nil
+			}
+			if status != entity.BackfillStatusRunning {
+				return nil
+			}
+			backfill.LastReportID = reportID
+		}
+	}
+}
+
+// backfillInterval is the time between two predictions at rate per second.
+func backfillInterval(rate float64) time.Duration {
+	if !(rate > 0) {
+		return maxBackfillInterval
+	}
+
+	interval := float64(time.Second) / rate
+	switch {
+	case interval > float64(maxBackfillInterval):
+		return maxBackfillInterval
+	case interval < float64(minBackfillInterval):
+		return minBackfillInterval
+	}
+
+	return time.Duration(interval)
+}
+
+// backfillReport re-runs prediction on a single report and records the result,
+// returning the status of the backfill afterwards. Reports already predicted
+// by the current model are skipped. An error is only returned when the
+// backfill cannot go on, such as when the predict service is unavailable.
+func (s *PredictionBackfillServiceImpl) backfillReport(
+	ctx context.Context,
+	backfill *entity.PredictionBackfill,
+	reportID int,
+	limiter <-chan time.Time) (string, error) {
+	const op = "PredictionBackfillServiceImpl.backfillReport"
+	if backfill.ModelVersion != nil {
+		exists, err := s.ReportPredictionRepository.Exists(ctx, s.App.DB, reportID, *backfill.ModelVersion)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return s.PredictionBackfillRepository.Advance(ctx, s.App.DB, backfill.ID, reportID, entity.BackfillResultSkipped, nil)
+		}
+	}
+
+	// A report withdrawn or deleted since its id was read has nothing left to
+	// predict, which is no reason to stop the backfill.
+	report, err := s.ReportRepository.Get(ctx, s.App.DB, reportID)
+	if err != nil {
+		if api.ExceptionCode(err) != api.ENOTFOUND {
+			return "", err
+		}
+		return s.PredictionBackfillRepository.Advance(ctx, s.App.DB, backfill.ID, reportID, entity.BackfillResultSkipped, nil)
+	}
+	if report.DeletedAt != nil {
+		return s.PredictionBackfillRepository.Advance(ctx, s.App.DB, backfill.ID, reportID, entity.BackfillResultSkipped, nil)
+	}
+
+	// A missing photo only fails this report, while an unavailable predict
+	// service would fail every report after it.
+	image, err := s.ImageFetcher.Fetch(ctx, report.ImageURL)
+	if err != nil {
+		message := err.Error()
+		return s.PredictionBackfillRepository.Advance(ctx, s.App.DB, backfill.ID, reportID, entity.BackfillResultFailed, &message)
+	}
+
+	<-limiter
+	result, err := s.Predictor.Predict(ctx, imageFilename(report.ImageURL), bytes.NewReader(image))
+	if err != nil {
+		if api.ExceptionCode(err) == api.EUNAVAILABLE {
+			return "", err
+		}
+		message := err.Error()
+		return s.PredictionBackfillRepository.Advance(ctx, s.App.DB, backfill.ID, reportID, entity.BackfillResultFailed, &message)
+	}
+
+	if result.ModelVersion == "" {
+		return "", api.NewSingleMessageException(
+			api.EUNAVAILABLE,
+			op,
+			"Prediction service does not report its model version",
+			errors.New("prediction without model version"),
+		)
+	}
+	if backfill.ModelVersion == nil || *backfill.ModelVersion != result.ModelVersion {
+		if err := s.PredictionBackfillRepository.SetModelVersion(ctx, s.App.DB, backfill.ID, result.ModelVersion); err != nil {
+			return "", err
+		}
+		backfill.ModelVersion = &result.ModelVersion
+	}
+	if report.ModelVersion != nil && *report.ModelVersion == result.ModelVersion {
+		return s.PredictionBackfillRepository.Advance(ctx, s.App.DB, backfill.ID, reportID, entity.BackfillResultSkipped, nil)
+	}
+
+	prediction := &entity.ReportPrediction{
+		ReportID:     reportID,
+		BackfillID:   &backfill.ID,
+		ModelVersion: result.ModelVersion,
+		Classes:      result.Classes,
+		Score:        &result.Score,
+		ClassScores:  result.ClassScores,
+		Detections:   result.Detections,
+	}
+
+	var status string
+	if err := driver.WithTransaction(s.App.DB, func(e driver.Executor) error {
+		created, err := s.ReportPredictionRepository.Create(ctx, e, prediction)
+		if err != nil {
+			return err
+		}
+
+		outcome := entity.BackfillResultProcessed
+		if !created {
+			outcome = entity.BackfillResultSkipped
+		}
+		status, err = s.PredictionBackfillRepository.Advance(ctx, e, backfill.ID, reportID, outcome, nil)
+
+		return err
+	}); err != nil {
+		return "", err
+	}
+
+	return status, nil
+}
+
+// imageFilename names the photo sent to the predict service after the stored
+// one, since the predict service checks its extension.
+func imageFilename(imageURL string) string {
+	u, err := url.Parse(imageURL)
+	if err != nil || path.Ext(u.Path) == "" {
+		return "image.jpg"
+	}
+
+	return path.Base(u.Path)
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestImageFilename(t *testing.T) {
+	cases := []struct {
+		imageURL string
+		want     string
+	}{
+		{"https://storage.googleapis.com/rodavis/reports/abc.png?alt=media", "abc.png"},
+		{"https://storage.googleapis.com/rodavis/reports/abc.jpg", "abc.jpg"},
+		{"https://storage.googleapis.com/rodavis/reports/abc", "image.jpg"},
+		{"%zz", "image.jpg"},
+	}
+
+	for _, c := range cases {
+		if got := imageFilename(c.imageURL); got != c.want {
+			t.Errorf("Expecting filename of %q to be %q but got %q instead", c.imageURL, c.want, got)
+		}
+	}
+}
+
+func TestBackfillInterval(t *testing.T) {
+	cases := []struct {
+		rate float64
+		want time.Duration
+	}{
+		{1, time.Second},
+		{4, 250 * time.Millisecond},
+		{1e-12, maxBackfillInterval},
+		{0, maxBackfillInterval},
+		{-1, maxBackfillInterval},
+		{math.NaN(), maxBackfillInterval},
+		{1e12, minBackfillInterval},
+	}
+
+	for _, c := range cases {
+		if got := backfillInterval(c.rate); got != c.want {
+			t.Errorf("Expecting interval at rate %v to be %s but got %s instead", c.rate, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+)
+
+// Predictor detects road damage in the photo of a report.
+type Predictor interface {
+	Predict(ctx context.Context, filename string, image io.Reader) (*model.PredictResult, error)
+}
+
+// PredictorHealthReporter is implemented by predictors that keep track of the
+// health of the predict service behind them.
+type PredictorHealthReporter interface {
+	Health() *model.PredictorHealth
+}
+
+// checkPredictResponse validates a prediction decoded from the response of a
+// predict service.
+func checkPredictResponse(op string, result *model.PredictResult) error {
+	if result == nil {
+		return invalidPrediction(op, "no prediction")
+	}
+	if result.ImageUrl == "" {
+		return invalidPrediction(op, "a prediction without image url")
+	}
+
+	return checkPrediction(op, result)
+}
+
+// checkPrediction rejects predictions a report cannot store and marks their
+// detections as found by the model. Missing classes, class scores and
+// detections, as a predict service finding no damage may answer, are made
+// empty so that every predictor reports no damage the same way.
+func checkPrediction(op string, result *model.PredictResult) error {
+	if !validScore(result.Score) {
+		return invalidPrediction(op, fmt.Sprintf("an invalid score %v", result.Score))
+	}
+
+	seen := make(map[string]bool, len(result.Classes))
+	for _, class := range result.Classes {
+		if !isReportClass(class) {
+			return invalidPrediction(op, fmt.Sprintf("an unknown class %q", class))
+		}
+		if seen[class] {
+			return invalidPrediction(op, fmt.Sprintf("class %s more than once", class))
+		}
+		seen[class] = true
+	}
+
+	for class, score := range result.ClassScores {
+		if !isReportClass(class) {
+			return invalidPrediction(op, fmt.Sprintf("a score for an unknown class %q", class))
+		}
+		if !validScore(score) {
+			return invalidPrediction(op, fmt.Sprintf("an invalid score %v for class %s", score, class))
+		}
+	}
+
+	for _, detection := range result.Detections {
+		if detection == nil || detection.Box == nil {
+			return invalidPrediction(op, "a detection without bounding box")
+		}
+		if !isReportClass(detection.Class) {
+			return invalidPrediction(op, fmt.Sprintf("a detection of an unknown class %q", detection.Class))
+		}
+		if !validScore(detection.Score) {
+			return invalidPrediction(op, fmt.Sprintf("a detection with an invalid score %v", detection.Score))
+		}
+		if !validBox(detection.Box) {
+			return invalidPrediction(op, fmt.Sprintf("an invalid bounding box %+v", *detection.Box))
+		}
+		detection.Source = entity.DetectionSourceModel
+	}
+
+	if result.Classes == nil {
+		result.Classes = []string{}
+	}
+	if result.ClassScores == nil {
+		result.ClassScores = map[string]float64{}
+	}
+	if result.Detections == nil {
+		result.Detections = []*entity.ReportDetection{}
+	}
+
+	return nil
+}
+
+// noResponseError marks a prediction that failed before the predict service
+// answered, such as a refused connection or a timeout. Only those are safe to
+// retry, since a service that answered may already have stored the photo.
+type noResponseError struct {
+	err error
+}
+
+func (e *noResponseError) Error() string {
+	return e.err.Error()
+}
+
+func (e *noResponseError) Unwrap() error {
+	return e.err
+}
+
+// noResponse tells whether err is an EUNAVAILABLE exception raised before the
+// predict service answered.
+func noResponse(err error) bool {
+	var nr *noResponseError
+	for err != nil {
+		exc, ok := err.(*api.Exception)
+		if !ok {
+			return errors.As(err, &nr)
+		}
+		err = exc.Err
+	}
+
+	return false
+}
+
+func invalidPrediction(op, what string) error {
+	return &api.Exception{
+		Op:  op,
+		Err: fmt.Errorf("prediction service returned %s", what),
+	}
+}
+
+func validScore(score float64) bool {
+	return !math.IsNaN(score) && !math.IsInf(score, 0) && score >= 0
+}
+
+func validBox(box *entity.Box) bool {
+	for _, v := range []float64{box.XMin, box.YMin, box.XMax, box.YMax} {
+		if math.IsNaN(v) || math.IsInf(v, 0) || v < 0 {
+			return false
+		}
+	}
+
+	return box.XMin <= box.XMax && box.YMin <= box.YMax
+}
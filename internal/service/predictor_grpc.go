@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/predictpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCPredictor sends the photo to a model server implementing the Predictor
+// service of predictpb.
+type GRPCPredictor struct {
+	client predictpb.PredictorClient
+}
+
+func NewGRPCPredictor(conn grpc.ClientConnInterface) *GRPCPredictor {
+	return &GRPCPredictor{
+		client: predictpb.NewPredictorClient(conn),
+	}
+}
+
+func (p *GRPCPredictor) Predict(ctx context.Context, filename string, image io.Reader) (*model.PredictResult, error) {
+	const op = "GRPCPredictor.Predict"
+	b, err := ioutil.ReadAll(image)
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"ioutil.ReadAll",
+			err,
+		)
+	}
+
+	timeoutCTX, cancel := context.WithTimeout(ctx, predictTimeout)
+	defer cancel()
+
+	res, err := p.client.Predict(timeoutCTX, &predictpb.PredictRequest{
+		Filename: filename,
+		Image:    b,
+	})
+	if err != nil {
+		switch status.Code(err) {
+		case codes.DeadlineExceeded:
+			return nil, api.NewSingleMessageException(
+				api.EUNAVAILABLE,
+				op,
+				"Timed out when trying to predict image. Please Try Again",
+				&noResponseError{err},
+			)
+		case codes.Unavailable:
+			return nil, api.NewSingleMessageException(
+				api.EUNAVAILABLE,
+				op,
+				"Prediction service is unavailable. Please Try Again",
+				&noResponseError{err},
+			)
+		}
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"p.client.Predict",
+			err,
+		)
+	}
+
+	result := &model.PredictResult{
+		ImageUrl:     res.ImageUrl,
+		Classes:      res.Classes,
+		Score:        res.Score,
+		ModelVersion: res.ModelVersion,
+		ClassScores:  res.ClassScores,
+	}
+	for _, detection := range res.Detections {
+		var box *entity.Box
+		if detection.Box != nil {
+			box = &entity.Box{
+				XMin: detection.Box.XMin,
+				YMin: detection.Box.YMin,
+				XMax: detection.Box.XMax,
+				YMax: detection.Box.YMax,
+			}
+		}
+		result.Detections = append(result.Detections, &entity.ReportDetection{
+			Class: detection.Class,
+			Score: detection.Score,
+			Box:   box,
+		})
+	}
+	if err := checkPredictResponse(op, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
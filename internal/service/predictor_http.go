@@ -0,0 +1,143 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"time"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+)
+
+const predictTimeout = 10 * time.Second
+
+// HTTPPredictor posts the photo as a multipart form to the predict service,
+// which answers with the prediction in the data field of its JSON response.
+type HTTPPredictor struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewHTTPPredictor(predictAPIURL string) *HTTPPredictor {
+	return &HTTPPredictor{
+		URL:    predictAPIURL,
+		Client: http.DefaultClient,
+	}
+}
+
+func (p *HTTPPredictor) Predict(ctx context.Context, filename string, image io.Reader) (*model.PredictResult, error) {
+	const op = "HTTPPredictor.Predict"
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+
+	part, err := writer.CreateFormFile("image", filename)
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"writer.CreateFormFile",
+			err,
+		)
+	}
+	_, err = io.Copy(part, image)
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"io.Copy",
+			err,
+		)
+	}
+	writer.Close()
+	timeoutCTX, cancel := context.WithTimeout(ctx, predictTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(timeoutCTX, http.MethodPost, p.URL, body)
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"http.NewRequestWithContext",
+			err,
+		)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	res, err := p.Client.Do(req)
+	if err != nil {
+		if uerr, ok := err.(*url.Error); ok && uerr.Timeout() {
+			return nil, api.NewSingleMessageException(
+				api.EUNAVAILABLE,
+				op,
+				"Timed out when trying to predict image. Please Try Again",
+				&noResponseError{err},
+			)
+		}
+		if ctx.Err() != nil {
+			return nil, api.NewExceptionWithSourceLocation(
+				op,
+				"p.Client.Do",
+				err,
+			)
+		}
+		// The request never got an answer, so the service is down or
+		// unreachable.
+		return nil, api.NewSingleMessageException(
+			api.EUNAVAILABLE,
+			op,
+			"Prediction service is unavailable. Please Try Again",
+			&noResponseError{err},
+		)
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusOK:
+	case res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError:
+		return nil, api.NewSingleMessageException(
+			api.EUNAVAILABLE,
+			op,
+			"Prediction service is unavailable. Please Try Again",
+			fmt.Errorf("prediction service returned %s", res.Status),
+		)
+	case res.StatusCode == http.StatusBadRequest || res.StatusCode == http.StatusUnsupportedMediaType:
+		return nil, api.NewSingleMessageException(
+			api.EINVALID,
+			op,
+			"Image cannot be predicted. Please upload a photo of the road",
+			fmt.Errorf("prediction service returned %s", res.Status),
+		)
+	default:
+		return nil, &api.Exception{
+			Op:  op,
+			Err: fmt.Errorf("prediction service returned %s", res.Status),
+		}
+	}
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"ioutil.ReadAll",
+			err,
+		)
+	}
+	predictResult := struct {
+		Data *model.PredictResult `json:"data"`
+	}{}
+	if err := json.Unmarshal(resBody, &predictResult); err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"json.Unmarshal",
+			err,
+		)
+	}
+	if err := checkPredictResponse(op, predictResult.Data); err != nil {
+		return nil, err
+	}
+
+	return predictResult.Data, nil
+}
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"sync"
+	"time"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+)
+
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// ResilientPredictor guards another predictor. It bounds the predictions in
+// flight, retries the ones the predict service could not answer with a
+// jittered exponential backoff and, after too many failures in a row, opens a
+// circuit breaker that fails fast until the service recovers.
+//
+// EUNAVAILABLE errors are counted as failures, but only the ones raised before
+// the service answered, such as a refused connection or a timeout, are
+// retried. Predicting uploads the photo, so a service that answered with an
+// error, 429 and 5xx included, may already have stored it and is not asked
+// again. The other errors come from a service that answered, so trying again
+// would not change them.
+type ResilientPredictor struct {
+	Predictor Predictor
+	// MaxConcurrency is how many predictions may be in flight at once, and
+	// QueueTimeout how long a prediction waits for its turn.
+	MaxConcurrency int
+	QueueTimeout   time.Duration
+	// MaxAttempts includes the first one. Every attempt has AttemptTimeout
+	// and all of them together have Timeout.
+	MaxAttempts    int
+	AttemptTimeout time.Duration
+	Timeout        time.Duration
+	// The backoff before the nth retry is random, up to BaseBackoff * 2^n
+	// capped at MaxBackoff.
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// FailureThreshold consecutive failures open the circuit for
+	// OpenTimeout, after which a single prediction is let through to probe
+	// the service.
+	FailureThreshold int
+	OpenTimeout      time.Duration
+
+	slots chan struct{}
+	now   func() time.Time
+
+	mu                  sync.Mutex
+	state               string
+	consecutiveFailures int
+	openedAt            time.Time
+	probing             bool
+}
+
+func NewResilientPredictor(predictor Predictor, maxConcurrency, maxAttempts int) *ResilientPredictor {
+	if maxConcurrency < 1 {
+		maxConcurrency = 1
+	}
+
+	return &ResilientPredictor{
+		Predictor:        predictor,
+		MaxConcurrency:   maxConcurrency,
+		QueueTimeout:     2 * time.Second,
+		MaxAttempts:      maxAttempts,
+		AttemptTimeout:   4 * time.Second,
+		Timeout:          predictTimeout,
+		BaseBackoff:      100 * time.Millisecond,
+		MaxBackoff:       time.Second,
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+		slots:            make(chan struct{}, maxConcurrency),
+		now:              time.Now,
+		state:            CircuitClosed,
+	}
+}
+
+var errCircuitOpen = errors.New("prediction circuit breaker is open")
+
+func (p *ResilientPredictor) Predict(ctx context.Context, filename string, image io.Reader) (*model.PredictResult, error) {
+	const op = "ResilientPredictor.Predict"
+	if p.rejecting() {
+		return nil, unavailable(op, errCircuitOpen)
+	}
+
+	// Every attempt needs the whole image again.
+	b, err := ioutil.ReadAll(image)
+	if err != nil {
+		return nil, api.NewExceptionWithSourceLocation(
+			op,
+			"ioutil.ReadAll",
+			err,
+		)
+	}
+
+	timeoutCTX, cancel := context.WithTimeout(ctx, p.Timeout)
+	defer cancel()
+
+	if err := p.acquire(timeoutCTX); err != nil {
+		return nil, api.NewSingleMessageException(
+			api.EUNAVAILABLE,
+			op,
+			"Prediction service is busy. Please Try Again",
+			err,
+		)
+	}
+	defer func() { <-p.slots }()
+
+	maxAttempts := p.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-time.After(p.backoff(attempt - 1)):
+			case <-timeoutCTX.Done():
+				return nil, err
+			}
+		}
+
+		probe, ok := p.allow()
+		if !ok {
+			if err == nil {
+				err = unavailable(op, errCircuitOpen)
+			}
+			return nil, err
+		}
+
+		var result *model.PredictResult
+		result, err = p.attempt(timeoutCTX, filename, b)
+		if err != nil && ctx.Err() != nil {
+			// A cancelled request says nothing about the service.
+			p.abandon(probe)
+			return nil, err
+		}
+		if err == nil || api.ExceptionCode(err) != api.EUNAVAILABLE {
+			p.record(probe, false)
+			return result, err
+		}
+		p.record(probe, true)
+		if !noResponse(err) {
+			return nil, err
+		}
+	}
+
+	return nil, err
+}
+
+func (p *ResilientPredictor) attempt(ctx context.Context, filename string, image []byte) (*model.PredictResult, error) {
+	attemptCTX, cancel := context.WithTimeout(ctx, p.AttemptTimeout)
+	defer cancel()
+
+	return p.Predictor.Predict(attemptCTX, filename, bytes.NewReader(image))
+}
+
+func (p *ResilientPredictor) acquire(ctx context.Context) error {
+	timer := time.NewTimer(p.QueueTimeout)
+	defer timer.Stop()
+
+	select {
+	case p.slots <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return errors.New("timed out waiting for a prediction slot")
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *ResilientPredictor) backoff(retry int) time.Duration {
+	max := p.BaseBackoff << (retry - 1)
+	if max > p.MaxBackoff || max <= 0 {
+		max = p.MaxBackoff
+	}
+	if max <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(max)))
+}
+
+// rejecting tells whether the circuit fails predictions fast, without taking
+// a turn to probe the service.
+func (p *ResilientPredictor) rejecting() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch p.state {
+	case CircuitOpen:
+		return p.now().Sub(p.openedAt) < p.OpenTimeout
+	case CircuitHalfOpen:
+		return p.probing
+	}
+
+	return false
+}
+
+// allow tells whether an attempt may go through, and whether it is the probe
+// of a half-open circuit. Once the circuit has been open for OpenTimeout, the
+// first attempt to ask becomes the probe.
+func (p *ResilientPredictor) allow() (probe, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch p.state {
+	case CircuitOpen:
+		if p.now().Sub(p.openedAt) < p.OpenTimeout {
+			return false, false
+		}
+		p.state = CircuitHalfOpen
+	case CircuitHalfOpen:
+		if p.probing {
+			return false, false
+		}
+	default:
+		return false, true
+	}
+	p.probing = true
+
+	return true, true
+}
+
+// record counts the outcome of an attempt. A success closes the circuit, while
+// a failed probe or too many failures in a row open it.
+func (p *ResilientPredictor) record(probe, failed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if probe {
+		p.probing = false
+	}
+	if !failed {
+		p.state = CircuitClosed
+		p.consecutiveFailures = 0
+		return
+	}
+
+	p.consecutiveFailures++
+	if p.state == CircuitHalfOpen || p.consecutiveFailures >= p.FailureThreshold {
+		p.state = CircuitOpen
+		p.openedAt = p.now()
+	}
+}
+
+// abandon gives the probe back without counting its attempt.
+func (p *ResilientPredictor) abandon(probe bool) {
+	if !probe {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.probing = false
+}
+
+func (p *ResilientPredictor) Health() *model.PredictorHealth {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	health := &model.PredictorHealth{
+		State:               p.state,
+		ConsecutiveFailures: p.consecutiveFailures,
+		InFlight:            len(p.slots),
+		MaxConcurrency:      p.MaxConcurrency,
+	}
+	if p.state != CircuitClosed {
+		openedAt := p.openedAt
+		health.OpenedAt = &openedAt
+	}
+
+	return health
+}
+
+func unavailable(op string, err error) error {
+	return api.NewSingleMessageException(
+		api.EUNAVAILABLE,
+		op,
+		"Prediction service is unavailable. Please Try Again",
+		err,
+	)
+}
@@ -0,0 +1,390 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/predictpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestFakePredictor(t *testing.T) {
+	wall := []byte("wall")
+	sum := sha256.Sum256(wall)
+	predictor := NewFakePredictor(nil,
+		&FakePredictRule{
+			ImageHash: hex.EncodeToString(sum[:]),
+		},
+		&FakePredictRule{
+			Filename: "pothole*.jpg",
+			Result: &model.PredictResult{
+				Classes:      []string{"D40"},
+				Score:        80,
+				ModelVersion: "pothole-v1",
+			},
+		},
+	)
+
+	cases := []struct {
+		filename     string
+		image        []byte
+		classes      int
+		modelVersion string
+	}{
+		{"pothole-1.jpg", wall, 0, FakeModelVersion},
+		{"pothole-1.jpg", []byte("road"), 1, "pothole-v1"},
+		{"road.jpg", []byte("road"), 1, FakeModelVersion},
+	}
+
+	for _, c := range cases {
+		result, err := predictor.Predict(context.Background(), c.filename, bytes.NewReader(c.image))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(result.Classes) != c.classes || result.ModelVersion != c.modelVersion {
+			t.Errorf("%s: expecting %d classes from %s but got %+v instead", c.filename, c.classes, c.modelVersion, result)
+		}
+	}
+
+	result, _ := predictor.Predict(context.Background(), "road.jpg", bytes.NewReader(nil))
+	if want := FakeImageURL + "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855.jpg"; result.ImageUrl != want {
+		t.Errorf("Expecting image url to be %s but got %s instead", want, result.ImageUrl)
+	}
+
+	if result.Detections[0].Source != entity.DetectionSourceModel {
+		t.Errorf("Expecting detections from the model but got %q instead", result.Detections[0].Source)
+	}
+
+	result.Classes[0] = "D50"
+	if predictor.Default.Classes[0] != "D00" {
+		t.Errorf("Expecting predictions not to share the default result")
+	}
+}
+
+func TestCheckPredictResponse(t *testing.T) {
+	cases := []struct {
+		name   string
+		result *model.PredictResult
+		valid  bool
+	}{
+		{"no prediction", nil, false},
+		{"no image url", &model.PredictResult{}, false},
+		{"no damage", &model.PredictResult{ImageUrl: "road.jpg"}, true},
+		{"unknown class", &model.PredictResult{ImageUrl: "road.jpg", Classes: []string{"D99"}}, false},
+		{"repeated class", &model.PredictResult{ImageUrl: "road.jpg", Classes: []string{"D00", "D00"}}, false},
+		{"negative score", &model.PredictResult{ImageUrl: "road.jpg", Score: -1}, false},
+		{
+			"unknown class score",
+			&model.PredictResult{ImageUrl: "road.jpg", ClassScores: map[string]float64{"D99": 50}},
+			false,
+		},
+		{
+			"no bounding box",
+			&model.PredictResult{ImageUrl: "road.jpg", Detections: []*entity.ReportDetection{{Class: "D00"}}},
+			false,
+		},
+		{
+			"inverted bounding box",
+			&model.PredictResult{
+				ImageUrl:   "road.jpg",
+				Detections: []*entity.ReportDetection{{Class: "D00", Box: &entity.Box{XMin: 10, XMax: 5}}},
+			},
+			false,
+		},
+	}
+
+	for _, c := range cases {
+		if err := checkPredictResponse("test", c.result); (err == nil) != c.valid {
+			t.Errorf("%s: expecting valid to be %t but got %v instead", c.name, c.valid, err)
+		}
+	}
+}
+
+func TestHTTPPredictor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, header, err := r.FormFile("image"); err != nil || header.Filename != "road.jpg" {
+			t.Errorf("Expecting road.jpg as the image form file but got %v instead", err)
+		}
+
+		api.NewResponse(http.StatusOK, "OK", &model.PredictResult{
+			ImageUrl: "road.jpg",
+			Classes:  []string{"D00"},
+			Detections: []*entity.ReportDetection{
+				{Class: "D00", Score: 90, Box: &entity.Box{XMax: 10, YMax: 10}},
+			},
+		}).SendJSON(w)
+	}))
+	defer server.Close()
+
+	result, err := NewHTTPPredictor(server.URL).Predict(context.Background(), "road.jpg", bytes.NewReader([]byte("road")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Detections) != 1 || result.Detections[0].Source != entity.DetectionSourceModel {
+		t.Errorf("Expecting 1 detection from the model but got %+v instead", result.Detections)
+	}
+}
+
+func TestHTTPPredictorNoDamage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":{"imageUrl":"road.jpg","classes":null,"score":0}}`))
+	}))
+	defer server.Close()
+
+	result, err := NewHTTPPredictor(server.URL).Predict(context.Background(), "road.jpg", bytes.NewReader([]byte("road")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Classes == nil || result.ClassScores == nil || result.Detections == nil {
+		t.Errorf("Expecting empty classes, class scores and detections but got %+v instead", result)
+	}
+}
+
+func TestHTTPPredictorErrors(t *testing.T) {
+	cases := []struct {
+		status int
+		body   string
+		code   string
+	}{
+		{http.StatusServiceUnavailable, "", api.EUNAVAILABLE},
+		{http.StatusTooManyRequests, "", api.EUNAVAILABLE},
+		{http.StatusBadRequest, "", api.EINVALID},
+		{http.StatusNotFound, "", api.EINTERNAL},
+		{http.StatusOK, `{"status": 200, "message": "OK", "data": null}`, api.EINTERNAL},
+	}
+
+	for _, c := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(c.status)
+			io.WriteString(w, c.body)
+		}))
+
+		_, err := NewHTTPPredictor(server.URL).Predict(context.Background(), "road.jpg", bytes.NewReader(nil))
+		if api.ExceptionCode(err) != c.code {
+			t.Errorf("%d: expecting %s but got %v instead", c.status, c.code, err)
+		}
+		if noResponse(err) {
+			t.Errorf("%d: expecting an answered request not to be retryable", c.status)
+		}
+		server.Close()
+	}
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	_, err := NewHTTPPredictor(server.URL).Predict(context.Background(), "road.jpg", bytes.NewReader(nil))
+	if api.ExceptionCode(err) != api.EUNAVAILABLE || !noResponse(err) {
+		t.Errorf("Expecting a refused connection to be retryable but got %v instead", err)
+	}
+}
+
+type predictFunc func(ctx context.Context, filename string, image io.Reader) (*model.PredictResult, error)
+
+func (f predictFunc) Predict(ctx context.Context, filename string, image io.Reader) (*model.PredictResult, error) {
+	return f(ctx, filename, image)
+}
+
+func newTestResilientPredictor(predictor Predictor) *ResilientPredictor {
+	p := NewResilientPredictor(predictor, 1, 3)
+	p.BaseBackoff = time.Millisecond
+	p.MaxBackoff = time.Millisecond
+	p.FailureThreshold = 3
+
+	return p
+}
+
+func TestResilientPredictorRetry(t *testing.T) {
+	calls := 0
+	p := newTestResilientPredictor(predictFunc(func(ctx context.Context, filename string, image io.Reader) (*model.PredictResult, error) {
+		calls++
+		if b, _ := ioutil.ReadAll(image); string(b) != "road" {
+			t.Errorf("Expecting every attempt to get the whole image but got %q instead", b)
+		}
+		if calls < 3 {
+			return nil, unavailable("test", &noResponseError{errors.New("pod is restarting")})
+		}
+		return &model.PredictResult{ImageUrl: "road.jpg"}, nil
+	}))
+
+	if _, err := p.Predict(context.Background(), "road.jpg", bytes.NewReader([]byte("road"))); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 3 {
+		t.Errorf("Expecting 3 attempts but got %d instead", calls)
+	}
+
+	if health := p.Health(); health.State != CircuitClosed || health.ConsecutiveFailures != 0 {
+		t.Errorf("Expecting a closed circuit without failures but got %+v instead", health)
+	}
+
+	calls = 0
+	p.Predictor = predictFunc(func(context.Context, string, io.Reader) (*model.PredictResult, error) {
+		calls++
+		return nil, unavailable("test", errors.New("prediction service returned 503 Service Unavailable"))
+	})
+	if _, err := p.Predict(context.Background(), "road.jpg", bytes.NewReader(nil)); api.ExceptionCode(err) != api.EUNAVAILABLE || calls != 1 {
+		t.Errorf("Expecting an answered request not to be retried but got %d attempts and %v", calls, err)
+	}
+
+	if health := p.Health(); health.ConsecutiveFailures != 1 {
+		t.Errorf("Expecting an answered request to count as a failure but got %+v instead", health)
+	}
+
+	calls = 0
+	p.Predictor = predictFunc(func(context.Context, string, io.Reader) (*model.PredictResult, error) {
+		calls++
+		return nil, &api.Exception{Op: "test", Err: errors.New("invalid prediction")}
+	})
+	if _, err := p.Predict(context.Background(), "road.jpg", bytes.NewReader(nil)); err == nil || calls != 1 {
+		t.Errorf("Expecting an invalid prediction not to be retried but got %d attempts and %v", calls, err)
+	}
+}
+
+func TestResilientPredictorCircuitBreaker(t *testing.T) {
+	calls := 0
+	failing := true
+	p := newTestResilientPredictor(predictFunc(func(context.Context, string, io.Reader) (*model.PredictResult, error) {
+		calls++
+		if failing {
+			return nil, unavailable("test", &noResponseError{errors.New("pod is restarting")})
+		}
+		return &model.PredictResult{ImageUrl: "road.jpg"}, nil
+	}))
+	now := time.Now()
+	p.now = func() time.Time { return now }
+
+	if _, err := p.Predict(context.Background(), "road.jpg", bytes.NewReader(nil)); api.ExceptionCode(err) != api.EUNAVAILABLE {
+		t.Fatalf("Expecting %s but got %v instead", api.EUNAVAILABLE, err)
+	}
+
+	if health := p.Health(); health.State != CircuitOpen || calls != 3 {
+		t.Fatalf("Expecting an open circuit after 3 attempts but got %+v after %d instead", health, calls)
+	}
+
+	if _, err := p.Predict(context.Background(), "road.jpg", bytes.NewReader(nil)); api.ExceptionCode(err) != api.EUNAVAILABLE || calls != 3 {
+		t.Errorf("Expecting an open circuit to fail fast but got %d attempts and %v", calls, err)
+	}
+
+	now = now.Add(p.OpenTimeout)
+	if _, err := p.Predict(context.Background(), "road.jpg", bytes.NewReader(nil)); err == nil || calls != 4 {
+		t.Errorf("Expecting a single failed probe but got %d attempts and %v", calls, err)
+	}
+
+	if health := p.Health(); health.State != CircuitOpen {
+		t.Errorf("Expecting a failed probe to open the circuit again but got %+v instead", health)
+	}
+
+	now = now.Add(p.OpenTimeout)
+	failing = false
+	if _, err := p.Predict(context.Background(), "road.jpg", bytes.NewReader(nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	if health := p.Health(); health.State != CircuitClosed {
+		t.Errorf("Expecting a succeeded probe to close the circuit but got %+v instead", health)
+	}
+}
+
+func TestResilientPredictorConcurrency(t *testing.T) {
+	started, done := make(chan struct{}), make(chan struct{})
+	p := newTestResilientPredictor(predictFunc(func(context.Context, string, io.Reader) (*model.PredictResult, error) {
+		close(started)
+		<-done
+		return &model.PredictResult{ImageUrl: "road.jpg"}, nil
+	}))
+	p.QueueTimeout = 10 * time.Millisecond
+
+	go p.Predict(context.Background(), "road.jpg", bytes.NewReader(nil))
+	<-started
+
+	if health := p.Health(); health.InFlight != 1 || health.MaxConcurrency != 1 {
+		t.Errorf("Expecting 1 of 1 prediction in flight but got %+v instead", health)
+	}
+
+	if _, err := p.Predict(context.Background(), "road.jpg", bytes.NewReader(nil)); api.ExceptionCode(err) != api.EUNAVAILABLE {
+		t.Errorf("Expecting %s while every slot is taken but got %v instead", api.EUNAVAILABLE, err)
+	}
+	close(done)
+}
+
+type predictServer struct {
+	predictpb.UnimplementedPredictorServer
+	err error
+}
+
+func (s *predictServer) Predict(ctx context.Context, req *predictpb.PredictRequest) (*predictpb.PredictResponse, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	return &predictpb.PredictResponse{
+		ImageUrl:     req.Filename,
+		Classes:      []string{"D20"},
+		Score:        float64(len(req.Image)),
+		ClassScores:  map[string]float64{"D20": 70},
+		ModelVersion: "grpc-v1",
+		Detections: []*predictpb.Detection{
+			{Class: "D20", Score: 70, Box: &predictpb.Box{XMin: 1, YMin: 2, XMax: 3, YMax: 4}},
+		},
+	}, nil
+}
+
+func TestGRPCPredictor(t *testing.T) {
+	listener := bufconn.Listen(1 << 20)
+	server := &predictServer{}
+	grpcServer := grpc.NewServer()
+	predictpb.RegisterPredictorServer(grpcServer, server)
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.Dial(
+		"bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		}),
+		grpc.WithInsecure(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	predictor := NewGRPCPredictor(conn)
+
+	result, err := predictor.Predict(context.Background(), "road.jpg", bytes.NewReader([]byte("road")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.ImageUrl != "road.jpg" || result.Score != 4 || result.ModelVersion != "grpc-v1" || result.ClassScores["D20"] != 70 {
+		t.Errorf("Expecting the prediction of road.jpg but got %+v instead", result)
+	}
+
+	if len(result.Detections) != 1 || *result.Detections[0].Box != (entity.Box{XMin: 1, YMin: 2, XMax: 3, YMax: 4}) {
+		t.Errorf("Expecting 1 detection but got %+v instead", result.Detections)
+	}
+
+	server.err = status.Error(codes.Unavailable, "model is loading")
+	if _, err := predictor.Predict(context.Background(), "road.jpg", bytes.NewReader(nil)); api.ExceptionCode(err) != api.EUNAVAILABLE {
+		t.Errorf("Expecting %s but got %v instead", api.EUNAVAILABLE, err)
+	}
+}
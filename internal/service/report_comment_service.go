@@ -0,0 +1,18 @@
+package service
+
+import (
+	"context"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+)
+
+type ReportCommentService interface {
+	Create(
+		ctx context.Context,
+		user *model.UserPayload,
+		reportID int,
+		createReportCommentDTO *model.CreateReportCommentDTO,
+	) (*entity.ReportComment, error)
+	GetAllByReportID(ctx context.Context, user *model.UserPayload, reportID int) ([]*entity.ReportComment, error)
+}
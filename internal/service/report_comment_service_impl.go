@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/config"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/repository"
+)
+
+const roleAdmin = "ADMIN"
+
+type ReportCommentServiceImpl struct {
+	*config.App
+	repository.ReportRepository
+	repository.ReportCommentRepository
+}
+
+func NewReportCommentService(
+	app *config.App,
+	reportRepo repository.ReportRepository,
+	commentRepo repository.ReportCommentRepository) ReportCommentService {
+	return &ReportCommentServiceImpl{
+		App:                     app,
+		ReportRepository:        reportRepo,
+		ReportCommentRepository: commentRepo,
+	}
+}
+
+// Create adds a comment to a report. Only admins and the reporter take part in
+// the conversation, and only admins can write internal comments.
+func (s *ReportCommentServiceImpl) Create(
+	ctx context.Context,
+	user *model.UserPayload,
+	reportID int,
+	createReportCommentDTO *model.CreateReportCommentDTO) (*entity.ReportComment, error) {
+	const op = "ReportCommentServiceImpl.Create"
+	report, err := s.ReportRepository.Get(ctx, s.App.DB, reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canView(user, report) {
+		return nil, hiddenReport(op)
+	}
+
+	isAdmin := user.Role == roleAdmin
+	if !isAdmin && report.UserID != user.ID {
+		return nil, api.NewSingleMessageException(
+			api.EFORBIDDEN,
+			op,
+			"Only the reporter can comment on this report",
+			errors.New("trying to comment on someone else's report"),
+		)
+	}
+
+	if !isAdmin && createReportCommentDTO.Internal {
+		return nil, api.NewSingleMessageException(
+			api.EFORBIDDEN,
+			op,
+			"Only admins can write internal comments",
+			errors.New("trying to write internal comment without admin role"),
+		)
+	}
+
+	comment := &entity.ReportComment{
+		ReportID: reportID,
+		UserID:   user.ID,
+		Body:     createReportCommentDTO.Body,
+		Internal: createReportCommentDTO.Internal,
+	}
+
+	return s.ReportCommentRepository.Create(ctx, s.App.DB, comment)
+}
+
+// GetAllByReportID lists the comments of a report, leaving out internal ones
+// unless user is an admin.
+func (s *ReportCommentServiceImpl) GetAllByReportID(
+	ctx context.Context,
+	user *model.UserPayload,
+	reportID int) ([]*entity.ReportComment, error) {
+	const op = "ReportCommentServiceImpl.GetAllByReportID"
+	report, err := s.ReportRepository.Get(ctx, s.App.DB, reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canView(user, report) {
+		return nil, hiddenReport(op)
+	}
+
+	return s.ReportCommentRepository.GetAllByReportID(ctx, s.App.DB, reportID, user.Role == roleAdmin)
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+)
+
+// ReviewThresholds is the confidence a predicted class needs for a report to
+// be published without review, in the same unit as the scores of the predict
+// service. Default applies to classes without a threshold of their own.
+type ReviewThresholds struct {
+	Default float64
+	Classes map[string]float64
+}
+
+// ParseClassThresholds parses a comma separated list of class=threshold pairs,
+// such as "D00=60,D40=75".
+func ParseClassThresholds(s string) (map[string]float64, error) {
+	thresholds := map[string]float64{}
+	if strings.TrimSpace(s) == "" {
+		return thresholds, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid class threshold %q, expecting class=threshold", pair)
+		}
+
+		class := strings.TrimSpace(parts[0])
+		if !isReportClass(class) {
+			return nil, fmt.Errorf("unknown class %q", class)
+		}
+
+		threshold, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid threshold for %s: %w", class, err)
+		}
+		thresholds[class] = threshold
+	}
+
+	return thresholds, nil
+}
+
+func isReportClass(class string) bool {
+	for _, c := range entity.ReportClasses {
+		if c == class {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *ReviewThresholds) threshold(class string) float64 {
+	if threshold, ok := t.Classes[class]; ok {
+		return threshold
+	}
+
+	return t.Default
+}
+
+// reviewReason tells why a prediction has to be reviewed by an admin before
+// its report is published, or returns an empty string when it does not. A
+// prediction without damage is always reviewed, while one with damage only
+// when none of its classes reach their threshold.
+func (t *ReviewThresholds) reviewReason(result *model.PredictResult) string {
+	if len(result.Classes) == 0 {
+		return entity.ReviewReasonNoDamage
+	}
+
+	if t == nil {
+		return ""
+	}
+
+	for _, class := range result.Classes {
+		score, ok := result.ClassScores[class]
+		if !ok {
+			score = result.Score
+		}
+		if score >= t.threshold(class) {
+			return ""
+		}
+	}
+
+	return entity.ReviewReasonLowConfidence
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"testing"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
+)
+
+func TestParseClassThresholds(t *testing.T) {
+	thresholds, err := ParseClassThresholds(" D00=60, D40=75.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if thresholds["D00"] != 60 || thresholds["D40"] != 75.5 || len(thresholds) != 2 {
+		t.Errorf("Expecting D00=60 and D40=75.5 but got %v instead", thresholds)
+	}
+
+	for _, s := range []string{"D00", "D99=10", "D00=high"} {
+		if _, err := ParseClassThresholds(s); err == nil {
+			t.Errorf("Expecting an error for %q", s)
+		}
+	}
+
+	if thresholds, err := ParseClassThresholds(""); err != nil || len(thresholds) != 0 {
+		t.Errorf("Expecting no thresholds but got %v, %v instead", thresholds, err)
+	}
+}
+
+func TestReviewReason(t *testing.T) {
+	thresholds := &ReviewThresholds{
+		Default: 50,
+		Classes: map[string]float64{"D40": 80},
+	}
+
+	cases := []struct {
+		name       string
+		thresholds *ReviewThresholds
+		result     *model.PredictResult
+		want       string
+	}{
+		{
+			name:       "no damage",
+			thresholds: thresholds,
+			result:     &model.PredictResult{Classes: []string{}},
+			want:       entity.ReviewReasonNoDamage,
+		},
+		{
+			name:       "no damage without thresholds",
+			thresholds: nil,
+			result:     &model.PredictResult{},
+			want:       entity.ReviewReasonNoDamage,
+		},
+		{
+			name:       "confident class",
+			thresholds: thresholds,
+			result: &model.PredictResult{
+				Classes:     []string{"D00", "D40"},
+				ClassScores: map[string]float64{"D00": 55, "D40": 70},
+			},
+			want: "",
+		},
+		{
+			name:       "no confident class",
+			thresholds: thresholds,
+			result: &model.PredictResult{
+				Classes:     []string{"D00", "D40"},
+				ClassScores: map[string]float64{"D00": 45, "D40": 79},
+			},
+			want: entity.ReviewReasonLowConfidence,
+		},
+		{
+			name:       "overall score without class scores",
+			thresholds: thresholds,
+			result: &model.PredictResult{
+				Classes: []string{"D00"},
+				Score:   40,
+			},
+			want: entity.ReviewReasonLowConfidence,
+		},
+		{
+			name:       "no thresholds",
+			thresholds: nil,
+			result: &model.PredictResult{
+				Classes: []string{"D00"},
+				Score:   1,
+			},
+			want: "",
+		},
+	}
+
+	for _, c := range cases {
+		if got := c.thresholds.reviewReason(c.result); got != c.want {
+			t.Errorf("%s: expecting review reason %q but got %q instead", c.name, c.want, got)
+		}
+	}
+}
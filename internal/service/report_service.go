@@ -15,7 +15,24 @@ type ReportService interface {
 		image multipart.File,
 		header *multipart.FileHeader,
 	) (*entity.Report, error)
-	GetAll(ctx context.Context, pagination *model.Pagination) ([]*entity.Report, error)
-	GetAllByUserID(ctx context.Context, userID int, pagination *model.Pagination) ([]*entity.Report, error)
-	Update(ctx context.Context, status string, reportID int) (*entity.Report, error)
+	Get(ctx context.Context, user *model.UserPayload, reportID int) (*entity.Report, error)
+	GetAll(ctx context.Context, pagination *model.Pagination, filter *model.ReportFilter) ([]*entity.Report, *model.ReportFacets, error)
+	GetAllByUserID(ctx context.Context, userID int, pagination *model.Pagination, filter *model.ReportFilter) ([]*entity.Report, *model.ReportFacets, error)
+	GetClusters(ctx context.Context, filter *model.ReportFilter, zoom int) (*model.ReportClusters, error)
+	GetTile(ctx context.Context, z, x, y int, filter *model.ReportFilter) ([]byte, error)
+	Export(ctx context.Context, filter *model.ReportFilter, fn func(*entity.Report) error) error
+	GetStats(ctx context.Context, filter *model.ReportFilter, interval string) (*model.ReportStats, error)
+	Update(ctx context.Context, userID, reportID int, updateReportDTO *model.UpdateReportDTO) (*entity.Report, error)
+	GetStatusHistory(ctx context.Context, user *model.UserPayload, reportID int) ([]*entity.ReportStatusHistory, error)
+	Merge(ctx context.Context, reportID, canonicalID int) (*entity.Report, error)
+	Split(ctx context.Context, reportID int) (*entity.Report, error)
+	Confirm(ctx context.Context, user *model.UserPayload, reportID int) (*entity.Report, error)
+	Unconfirm(ctx context.Context, user *model.UserPayload, reportID int) (*entity.Report, error)
+	Edit(ctx context.Context, userID, reportID int, editReportDTO *model.EditReportDTO) (*entity.Report, error)
+	Withdraw(ctx context.Context, userID, reportID int) (*entity.Report, error)
+	Restore(ctx context.Context, reportID int) (*entity.Report, error)
+	GetAnnotatedImage(ctx context.Context, reportID int) (*model.AnnotatedImage, error)
+	Relabel(ctx context.Context, userID, reportID int, relabelReportDTO *model.RelabelReportDTO) (*entity.Report, error)
+	ExportDataset(ctx context.Context, filter *model.ReportFilter, fn func(*entity.Report, []byte) error) (int, error)
+	GetShadowAgreement(ctx context.Context, filter *model.ReportFilter, modelVersion string) (*model.ShadowAgreement, error)
 }
@@ -3,21 +3,23 @@ package service
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
 	"mime/multipart"
 	"net/http"
-	"net/url"
 	"strings"
-	"time"
 
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/annotate"
 	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
 	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/config"
 	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/driver"
 	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/entity"
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/logger"
 	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/model"
 	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/repository"
 )
@@ -26,19 +28,56 @@ type ReportServiceImpl struct {
 	*config.App
 	repository.ReportRepository
 	repository.UserRepository
-	PredictAPIURL string
+	repository.ReportStatusHistoryRepository
+	repository.ReportConfirmationRepository
+	repository.ReportDetectionRepository
+	repository.ReportPredictionRepository
+	Predictor Predictor
+	// ShadowPredictor is a candidate model that new submissions are also
+	// sent to, in the background, so it can be compared with the primary
+	// one. Nil disables shadow predictions.
+	ShadowPredictor Predictor
+	// DuplicateRadius is how close in meters an open report with an
+	// overlapping class has to be for a new submission to be linked to it as
+	// a duplicate. Zero disables duplicate detection.
+	DuplicateRadius float64
+	// ReviewThresholds holds new submissions whose prediction is not
+	// confident enough for an admin to review. Nil only holds submissions
+	// without any damage detected.
+	ReviewThresholds *ReviewThresholds
+	// ImageFetcher downloads report photos to be annotated.
+	ImageFetcher    ImageFetcher
+	AnnotationCache *annotate.Cache
+	shadowSlots     chan struct{}
 }
 
 func NewReportService(
 	app *config.App,
 	reportRepo repository.ReportRepository,
 	userRepo repository.UserRepository,
-	predictAPIURL string) ReportService {
+	historyRepo repository.ReportStatusHistoryRepository,
+	confirmationRepo repository.ReportConfirmationRepository,
+	detectionRepo repository.ReportDetectionRepository,
+	predictionRepo repository.ReportPredictionRepository,
+	predictor Predictor,
+	shadowPredictor Predictor,
+	duplicateRadius float64,
+	reviewThresholds *ReviewThresholds) ReportService {
 	return &ReportServiceImpl{
-		App:              app,
-		ReportRepository: reportRepo,
-		UserRepository:   userRepo,
-		PredictAPIURL:    predictAPIURL,
+		App:                           app,
+		ReportRepository:              reportRepo,
+		UserRepository:                userRepo,
+		ReportStatusHistoryRepository: historyRepo,
+		ReportConfirmationRepository:  confirmationRepo,
+		ReportDetectionRepository:     detectionRepo,
+		ReportPredictionRepository:    predictionRepo,
+		Predictor:                     predictor,
+		ShadowPredictor:               shadowPredictor,
+		DuplicateRadius:               duplicateRadius,
+		ReviewThresholds:              reviewThresholds,
+		ImageFetcher:                  NewHTTPImageFetcher(http.DefaultClient),
+		AnnotationCache:               annotate.NewCache(annotationCacheSize),
+		shadowSlots:                   make(chan struct{}, maxShadowPredictions),
 	}
 }
 
@@ -58,113 +97,935 @@ func (s *ReportServiceImpl) Create(
 		)
 	}
 
-	body := new(bytes.Buffer)
-	writer := multipart.NewWriter(body)
+	var imageReader io.Reader = image
+	var shadowImage []byte
+	if s.ShadowPredictor != nil {
+		// The upload is removed once the request ends, so the shadow
+		// prediction needs its own copy.
+		var err error
+		shadowImage, err = ioutil.ReadAll(image)
+		if err != nil {
+			return nil, api.NewExceptionWithSourceLocation(
+				op,
+				"ioutil.ReadAll",
+				err,
+			)
+		}
+		imageReader = bytes.NewReader(shadowImage)
+	}
 
-	part, err := writer.CreateFormFile("image", header.Filename)
+	predictResult, err := s.Predictor.Predict(ctx, header.Filename, imageReader)
 	if err != nil {
-		return nil, api.NewExceptionWithSourceLocation(
+		return nil, err
+	}
+	report.ImageURL = predictResult.ImageUrl
+	report.Classes = predictResult.Classes
+	report.Score = &predictResult.Score
+	report.ClassScores = predictResult.ClassScores
+	if predictResult.ModelVersion != "" {
+		report.ModelVersion = &predictResult.ModelVersion
+	}
+	detections := predictResult.Detections
+	report.Status = StatusReported
+	if reason := s.ReviewThresholds.reviewReason(predictResult); reason != "" {
+		report.Status = StatusNeedsReview
+		report.ReviewReason = reason
+	}
+
+	if err := driver.WithTransaction(s.App.DB, func(e driver.Executor) error {
+		user, err := s.UserRepository.Get(ctx, e, report.UserID)
+		if err != nil {
+			return err
+		}
+
+		// Reports waiting for review are not published yet, so they are not
+		// linked to one that is.
+		if s.DuplicateRadius > 0 && report.Status == StatusReported {
+			// Without the lock, two submissions of the same damage at once
+			// would both miss each other and become canonical reports.
+			if err := s.ReportRepository.LockArea(ctx, e, report.Location, s.DuplicateRadius); err != nil {
+				return err
+			}
+			canonicalID, err := s.ReportRepository.FindDuplicate(ctx, e, report.Location, report.Classes, s.DuplicateRadius)
+			if err != nil {
+				return err
+			}
+			if canonicalID != 0 {
+				report.CanonicalID = &canonicalID
+			}
+		}
+
+		report, err = s.ReportRepository.Create(ctx, e, user.ID, report)
+		if err != nil {
+			return err
+		}
+		report.PredictedClasses = report.Classes
+		if err := s.ReportRepository.CreateClassScores(ctx, e, report.ID, report.ClassScores); err != nil {
+			return err
+		}
+		report.Detections, err = s.ReportDetectionRepository.CreateAll(ctx, e, report.ID, detections)
+		if err != nil {
+			return err
+		}
+		report.ReporterName = user.Name
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if shadowImage != nil {
+		s.shadowPredict(report.ID, header.Filename, shadowImage)
+	}
+
+	return report, nil
+}
+
+const (
+	maxShadowPredictions = 8
+	// unknownModelVersion is recorded for shadow predictions when the shadow
+	// service does not report its model version.
+	unknownModelVersion = "unknown"
+)
+
+// shadowPredict sends image to the shadow predict service in the background
+// and stores its result next to the primary prediction of the report. The
+// prediction is dropped when too many are already in flight, so a slow shadow
+// service never piles up work.
+func (s *ReportServiceImpl) shadowPredict(reportID int, filename string, image []byte) {
+	select {
+	case s.shadowSlots <- struct{}{}:
+	default:
+		return
+	}
+
+	shadowPredictor := s.ShadowPredictor
+	go func() {
+		defer func() { <-s.shadowSlots }()
+
+		const op = "ReportServiceImpl.shadowPredict"
+		ctx := context.Background()
+		result, err := shadowPredictor.Predict(ctx, filename, bytes.NewReader(image))
+		if err != nil {
+			logger.Error(op, &model.SourceLocation{Function: "shadowPredictor.Predict"}, err)
+			return
+		}
+
+		modelVersion := result.ModelVersion
+		if modelVersion == "" {
+			modelVersion = unknownModelVersion
+		}
+		if _, err := s.ReportPredictionRepository.Create(ctx, s.App.DB, &entity.ReportPrediction{
+			ReportID:     reportID,
+			ModelVersion: modelVersion,
+			Shadow:       true,
+			Classes:      result.Classes,
+			Score:        &result.Score,
+			ClassScores:  result.ClassScores,
+			Detections:   result.Detections,
+		}); err != nil {
+			logger.Error(op, &model.SourceLocation{Function: "s.ReportPredictionRepository.Create"}, err)
+		}
+	}()
+}
+
+// GetShadowAgreement summarises how often the shadow model agrees with the
+// primary one on every class.
+func (s *ReportServiceImpl) GetShadowAgreement(
+	ctx context.Context,
+	filter *model.ReportFilter,
+	modelVersion string) (*model.ShadowAgreement, error) {
+	agreementFilter := model.ReportFilter{}
+	if filter != nil {
+		agreementFilter = *filter
+	}
+	// Duplicates were predicted on their own photos too.
+	agreementFilter.IncludeDuplicates = true
+
+	return s.ReportPredictionRepository.GetShadowAgreement(ctx, s.App.DB, &agreementFilter, modelVersion)
+}
+
+// Get returns a report to user, who is nil when anonymous.
+func (s *ReportServiceImpl) Get(ctx context.Context, user *model.UserPayload, reportID int) (*entity.Report, error) {
+	const op = "ReportServiceImpl.Get"
+	report, err := s.get(ctx, reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canView(user, report) {
+		return nil, hiddenReport(op)
+	}
+
+	if canSeeHistory(user, report) {
+		report.StatusHistory, err = s.ReportStatusHistoryRepository.GetAllByReportID(ctx, s.App.DB, reportID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// get returns a report with its detections, whoever may see it.
+func (s *ReportServiceImpl) get(ctx context.Context, reportID int) (*entity.Report, error) {
+	report, err := s.ReportRepository.Get(ctx, s.App.DB, reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.loadDetections(ctx, s.App.DB, report); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// loadDetections fills the detections of report. Once an admin has relabeled
+// the boxes, theirs are the effective detections and the model's are kept
+// aside as predicted detections.
+func (s *ReportServiceImpl) loadDetections(ctx context.Context, e driver.Executor, report *entity.Report) error {
+	detections, err := s.ReportDetectionRepository.GetAllByReportID(ctx, e, report.ID)
+	if err != nil {
+		return err
+	}
+
+	report.Detections = nil
+	report.PredictedDetections = nil
+	for _, detection := range detections {
+		switch {
+		case detection.Source == entity.DetectionSourceModel && report.DetectionsRelabeled:
+			report.PredictedDetections = append(report.PredictedDetections, detection)
+		case detection.Source == entity.DetectionSourceModel || report.DetectionsRelabeled:
+			report.Detections = append(report.Detections, detection)
+		}
+	}
+
+	return nil
+}
+
+func (s *ReportServiceImpl) GetAll(
+	ctx context.Context,
+	pagination *model.Pagination,
+	filter *model.ReportFilter) ([]*entity.Report, *model.ReportFacets, error) {
+	reports, err := s.ReportRepository.GetAll(ctx, s.App.DB, pagination, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	facets, err := s.ReportRepository.GetFacets(ctx, s.App.DB, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reports, facets, nil
+}
+
+func (s *ReportServiceImpl) GetAllByUserID(
+	ctx context.Context,
+	userID int,
+	pagination *model.Pagination,
+	filter *model.ReportFilter) ([]*entity.Report, *model.ReportFacets, error) {
+	reports, err := s.ReportRepository.GetAllByUserID(ctx, s.App.DB, userID, pagination, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	userFilter := model.ReportFilter{}
+	if filter != nil {
+		userFilter = *filter
+	}
+	userFilter.ReporterID = userID
+	// Reporters always see their own submissions, including duplicates.
+	userFilter.IncludeDuplicates = true
+	userFilter.IncludeNeedsReview = true
+	facets, err := s.ReportRepository.GetFacets(ctx, s.App.DB, &userFilter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reports, facets, nil
+}
+
+func (s *ReportServiceImpl) GetClusters(ctx context.Context, filter *model.ReportFilter, zoom int) (*model.ReportClusters, error) {
+	clusters := &model.ReportClusters{
+		Zoom:     zoom,
+		Clusters: []*model.ReportCluster{},
+		Reports:  []*entity.Report{},
+	}
+
+	if zoom >= individualReportZoom {
+		pagination := &model.Pagination{
+			Limit: maxIndividualReports,
+		}
+		reports, err := s.ReportRepository.GetAll(ctx, s.App.DB, pagination, filter)
+		if err != nil {
+			return nil, err
+		}
+		clusters.Reports = reports
+
+		return clusters, nil
+	}
+
+	result, err := s.ReportRepository.GetClusters(ctx, s.App.DB, filter, clusterCellSize(zoom))
+	if err != nil {
+		return nil, err
+	}
+	clusters.Clusters = result
+
+	return clusters, nil
+}
+
+func (s *ReportServiceImpl) GetTile(ctx context.Context, z, x, y int, filter *model.ReportFilter) ([]byte, error) {
+	return s.ReportRepository.GetTile(ctx, s.App.DB, z, x, y, filter)
+}
+
+func (s *ReportServiceImpl) Export(ctx context.Context, filter *model.ReportFilter, fn func(*entity.Report) error) error {
+	return s.ReportRepository.Stream(ctx, s.App.DB, filter, fn)
+}
+
+func (s *ReportServiceImpl) GetStats(ctx context.Context, filter *model.ReportFilter, interval string) (*model.ReportStats, error) {
+	return s.ReportRepository.GetStats(ctx, s.App.DB, filter, interval)
+}
+
+func (s *ReportServiceImpl) Update(
+	ctx context.Context,
+	userID,
+	reportID int,
+	updateReportDTO *model.UpdateReportDTO) (*entity.Report, error) {
+	const op = "ReportServiceImpl.Update"
+	var report *entity.Report
+	if err := driver.WithTransaction(s.App.DB, func(e driver.Executor) error {
+		oldStatus, err := s.ReportRepository.GetStatusForUpdate(ctx, e, reportID)
+		if err != nil {
+			return err
+		}
+
+		if err := validateStatusTransition(op, oldStatus, updateReportDTO.Status); err != nil {
+			return err
+		}
+
+		report, err = s.ReportRepository.Update(ctx, e, updateReportDTO.Status, reportID)
+		if err != nil {
+			return err
+		}
+
+		history := &entity.ReportStatusHistory{
+			ReportID:  reportID,
+			UserID:    &userID,
+			OldStatus: oldStatus,
+			NewStatus: report.Status,
+			Note:      updateReportDTO.Note,
+		}
+		_, err = s.ReportStatusHistoryRepository.Create(ctx, e, history)
+
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// GetStatusHistory lists the status changes of a report to its reporter and
+// admins.
+func (s *ReportServiceImpl) GetStatusHistory(
+	ctx context.Context,
+	user *model.UserPayload,
+	reportID int) ([]*entity.ReportStatusHistory, error) {
+	const op = "ReportServiceImpl.GetStatusHistory"
+	report, err := s.ReportRepository.Get(ctx, s.App.DB, reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !canView(user, report) {
+		return nil, hiddenReport(op)
+	}
+
+	if !canSeeHistory(user, report) {
+		return nil, api.NewSingleMessageException(
+			api.EFORBIDDEN,
 			op,
-			"writer.CreateFormFile",
-			err,
+			"You can only see the history of your own report",
+			errors.New("trying to see the history of someone else's report"),
 		)
 	}
-	_, err = io.Copy(part, image)
+
+	return s.ReportStatusHistoryRepository.GetAllByReportID(ctx, s.App.DB, reportID)
+}
+
+// Merge links reportID to canonicalID as a duplicate. Duplicates of reportID
+// follow it so that every duplicate points directly at a canonical report.
+func (s *ReportServiceImpl) Merge(ctx context.Context, reportID, canonicalID int) (*entity.Report, error) {
+	const op = "ReportServiceImpl.Merge"
+	var report *entity.Report
+	if err := driver.WithTransaction(s.App.DB, func(e driver.Executor) error {
+		first, second := reportID, canonicalID
+		if first > second {
+			first, second = second, first
+		}
+		for _, id := range []int{first, second} {
+			if _, err := s.ReportRepository.GetStatusForUpdate(ctx, e, id); err != nil {
+				return err
+			}
+		}
+
+		canonical, err := s.ReportRepository.Get(ctx, e, canonicalID)
+		if err != nil {
+			return err
+		}
+		if canonical.CanonicalID != nil {
+			canonicalID = *canonical.CanonicalID
+			canonical, err = s.ReportRepository.Get(ctx, e, canonicalID)
+			if err != nil {
+				return err
+			}
+		}
+		if canonicalID == reportID {
+			return api.NewSingleMessageException(
+				api.ECONFLICT,
+				op,
+				"Report cannot be merged into itself",
+				errors.New("report merged into itself"),
+			)
+		}
+
+		// Only reports still open to the public can collect duplicates, the
+		// same ones new submissions are linked to.
+		if canonical.DeletedAt != nil {
+			return api.NewSingleMessageException(
+				api.ECONFLICT,
+				op,
+				"Report cannot be merged into a withdrawn report",
+				errors.New("report merged into a withdrawn report"),
+			)
+		}
+		switch canonical.Status {
+		case StatusCompleted, StatusRejected, StatusNeedsReview:
+			return api.NewSingleMessageException(
+				api.ECONFLICT,
+				op,
+				"Report can only be merged into an open report",
+				fmt.Errorf("report merged into a report that is %s", canonical.Status),
+			)
+		}
+
+		if err := s.ReportRepository.SetCanonical(ctx, e, reportID, &canonicalID); err != nil {
+			return err
+		}
+		if err := s.ReportRepository.ReassignDuplicates(ctx, e, reportID, canonicalID); err != nil {
+			return err
+		}
+
+		report, err = s.ReportRepository.Get(ctx, e, reportID)
+
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// Split turns a duplicate back into a canonical report of its own.
+func (s *ReportServiceImpl) Split(ctx context.Context, reportID int) (*entity.Report, error) {
+	const op = "ReportServiceImpl.Split"
+	var report *entity.Report
+	if err := driver.WithTransaction(s.App.DB, func(e driver.Executor) error {
+		if _, err := s.ReportRepository.GetStatusForUpdate(ctx, e, reportID); err != nil {
+			return err
+		}
+
+		current, err := s.ReportRepository.Get(ctx, e, reportID)
+		if err != nil {
+			return err
+		}
+		if current.CanonicalID == nil {
+			return api.NewSingleMessageException(
+				api.ECONFLICT,
+				op,
+				"Report is not a duplicate",
+				errors.New("report has no canonical report"),
+			)
+		}
+
+		if err := s.ReportRepository.SetCanonical(ctx, e, reportID, nil); err != nil {
+			return err
+		}
+
+		report, err = s.ReportRepository.Get(ctx, e, reportID)
+
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// Confirm records that userID has seen the damage of someone else's open
+// report.
+func (s *ReportServiceImpl) Confirm(ctx context.Context, user *model.UserPayload, reportID int) (*entity.Report, error) {
+	const op = "ReportServiceImpl.Confirm"
+	var report *entity.Report
+	if err := driver.WithTransaction(s.App.DB, func(e driver.Executor) error {
+		// The report is locked so that it cannot be closed, withdrawn or
+		// merged between the checks below and the confirmation.
+		if _, err := s.ReportRepository.GetStatusForUpdate(ctx, e, reportID); err != nil {
+			return err
+		}
+
+		var err error
+		report, err = s.ReportRepository.Get(ctx, e, reportID)
+		if err != nil {
+			return err
+		}
+
+		if !canView(user, report) {
+			return hiddenReport(op)
+		}
+
+		if report.UserID == user.ID {
+			return api.NewSingleMessageException(
+				api.ECONFLICT,
+				op,
+				"You cannot confirm your own report",
+				errors.New("trying to confirm own report"),
+			)
+		}
+
+		if report.Status == StatusCompleted || report.Status == StatusRejected {
+			return api.NewSingleMessageException(
+				api.ECONFLICT,
+				op,
+				fmt.Sprintf("Report is already %s", report.Status),
+				errors.New("trying to confirm a closed report"),
+			)
+		}
+
+		if report.DeletedAt != nil {
+			return api.NewSingleMessageException(
+				api.ECONFLICT,
+				op,
+				"Report has been withdrawn",
+				errors.New("trying to confirm a withdrawn report"),
+			)
+		}
+
+		if report.Status == StatusNeedsReview {
+			return api.NewSingleMessageException(
+				api.ECONFLICT,
+				op,
+				"Report is waiting for review",
+				errors.New("trying to confirm a report waiting for review"),
+			)
+		}
+
+		if err := s.ReportConfirmationRepository.Create(ctx, e, reportID, user.ID); err != nil {
+			return err
+		}
+		report.ConfirmationCount++
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (s *ReportServiceImpl) Unconfirm(ctx context.Context, user *model.UserPayload, reportID int) (*entity.Report, error) {
+	const op = "ReportServiceImpl.Unconfirm"
+	report, err := s.ReportRepository.Get(ctx, s.App.DB, reportID)
 	if err != nil {
-		return nil, api.NewExceptionWithSourceLocation(
+		return nil, err
+	}
+
+	if !canView(user, report) {
+		return nil, hiddenReport(op)
+	}
+
+	if err := s.ReportConfirmationRepository.Delete(ctx, s.App.DB, reportID, user.ID); err != nil {
+		return nil, err
+	}
+	report.ConfirmationCount--
+
+	return report, nil
+}
+
+// Edit lets a reporter correct the details of their report while nobody has
+// started working on it.
+func (s *ReportServiceImpl) Edit(
+	ctx context.Context,
+	userID,
+	reportID int,
+	editReportDTO *model.EditReportDTO) (*entity.Report, error) {
+	const op = "ReportServiceImpl.Edit"
+	var report *entity.Report
+	if err := driver.WithTransaction(s.App.DB, func(e driver.Executor) error {
+		current, err := s.getOwnedForUpdate(ctx, e, op, userID, reportID)
+		if err != nil {
+			return err
+		}
+
+		if editReportDTO.Note != nil {
+			current.Note = *editReportDTO.Note
+		}
+		if editReportDTO.Address != nil {
+			current.Address = *editReportDTO.Address
+		}
+		if editReportDTO.Location != nil {
+			current.Location = &entity.Location{
+				Lat: *editReportDTO.Location.Lat,
+				Lng: *editReportDTO.Location.Lng,
+			}
+		}
+
+		if err := s.ReportRepository.UpdateDetails(ctx, e, current); err != nil {
+			return err
+		}
+
+		report, err = s.ReportRepository.Get(ctx, e, reportID)
+
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// Withdraw soft deletes a report on behalf of its reporter. Its duplicates
+// become canonical reports of their own so they stay visible.
+func (s *ReportServiceImpl) Withdraw(ctx context.Context, userID, reportID int) (*entity.Report, error) {
+	const op = "ReportServiceImpl.Withdraw"
+	var report *entity.Report
+	if err := driver.WithTransaction(s.App.DB, func(e driver.Executor) error {
+		if _, err := s.getOwnedForUpdate(ctx, e, op, userID, reportID); err != nil {
+			return err
+		}
+
+		if err := s.ReportRepository.SetDeleted(ctx, e, reportID, true); err != nil {
+			return err
+		}
+		if err := s.ReportRepository.ReleaseDuplicates(ctx, e, reportID); err != nil {
+			return err
+		}
+
+		var err error
+		report, err = s.ReportRepository.Get(ctx, e, reportID)
+
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (s *ReportServiceImpl) Restore(ctx context.Context, reportID int) (*entity.Report, error) {
+	const op = "ReportServiceImpl.Restore"
+	var report *entity.Report
+	if err := driver.WithTransaction(s.App.DB, func(e driver.Executor) error {
+		if _, err := s.ReportRepository.GetStatusForUpdate(ctx, e, reportID); err != nil {
+			return err
+		}
+
+		current, err := s.ReportRepository.Get(ctx, e, reportID)
+		if err != nil {
+			return err
+		}
+		if current.DeletedAt == nil {
+			return api.NewSingleMessageException(
+				api.ECONFLICT,
+				op,
+				"Report is not withdrawn",
+				errors.New("trying to restore a report that is not withdrawn"),
+			)
+		}
+
+		if err := s.ReportRepository.SetDeleted(ctx, e, reportID, false); err != nil {
+			return err
+		}
+
+		report, err = s.ReportRepository.Get(ctx, e, reportID)
+
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// getOwnedForUpdate locks a report that userID is allowed to change: their
+// own, not withdrawn and still waiting to be reviewed or handled.
+func (s *ReportServiceImpl) getOwnedForUpdate(
+	ctx context.Context,
+	e driver.Executor,
+	op string,
+	userID,
+	reportID int) (*entity.Report, error) {
+	if _, err := s.ReportRepository.GetStatusForUpdate(ctx, e, reportID); err != nil {
+		return nil, err
+	}
+
+	report, err := s.ReportRepository.Get(ctx, e, reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	if report.UserID != userID {
+		return nil, api.NewSingleMessageException(
+			api.EFORBIDDEN,
 			op,
-			"io.Copy",
-			err,
+			"You can only change your own report",
+			errors.New("trying to change someone else's report"),
+		)
+	}
+
+	if report.DeletedAt != nil {
+		return nil, api.NewSingleMessageException(
+			api.ECONFLICT,
+			op,
+			"Report has been withdrawn",
+			errors.New("trying to change a withdrawn report"),
+		)
+	}
+
+	if report.Status != StatusReported && report.Status != StatusNeedsReview {
+		return nil, api.NewSingleMessageException(
+			api.ECONFLICT,
+			op,
+			fmt.Sprintf("Report can no longer be changed because it is %s", report.Status),
+			errors.New("trying to change a report that is already being handled"),
 		)
 	}
-	writer.Close()
-	timeoutCTX, cancel := context.WithTimeout(ctx, 10*time.Second)
-	defer cancel()
 
-	req, err := http.NewRequestWithContext(timeoutCTX, http.MethodPost, s.PredictAPIURL, body)
+	return report, nil
+}
+
+// canView tells whether user, nil when anonymous, may see report. Withdrawn
+// reports and reports waiting for review are only shown to their reporter
+// and admins.
+func canView(user *model.UserPayload, report *entity.Report) bool {
+	if report.DeletedAt == nil && report.Status != StatusNeedsReview {
+		return true
+	}
+
+	return user != nil && (user.Role == roleAdmin || user.ID == report.UserID)
+}
+
+// canSeeHistory tells whether user, nil when anonymous, may see the status
+// history of report, which names the admins who changed it.
+func canSeeHistory(user *model.UserPayload, report *entity.Report) bool {
+	return user != nil && (user.Role == roleAdmin || user.ID == report.UserID)
+}
+
+// hiddenReport answers like a missing report, so that hidden ones cannot be
+// told apart from those.
+func hiddenReport(op string) error {
+	return api.NewSingleMessageException(
+		api.ENOTFOUND,
+		op,
+		"Report Not Found",
+		errors.New("report is hidden from user"),
+	)
+}
+
+const annotationCacheSize = 128
+
+// GetAnnotatedImage returns the photo of a report with its detections drawn
+// on it. Rendered images are cached until the photo or detections change.
+func (s *ReportServiceImpl) GetAnnotatedImage(ctx context.Context, reportID int) (*model.AnnotatedImage, error) {
+	const op = "ReportServiceImpl.GetAnnotatedImage"
+	report, err := s.get(ctx, reportID)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := annotationKey(report)
 	if err != nil {
 		return nil, api.NewExceptionWithSourceLocation(
 			op,
-			"http.NewRequestWithContext",
+			"annotationKey",
 			err,
 		)
 	}
-	req.Header.Set("Content-Type", writer.FormDataContentType())
-	client := http.DefaultClient
-	res, err := client.Do(req)
+	if data, ok := s.AnnotationCache.Get(key); ok {
+		return &model.AnnotatedImage{Data: data, ETag: key}, nil
+	}
+
+	image, err := s.ImageFetcher.Fetch(ctx, report.ImageURL)
 	if err != nil {
-		if uerr, ok := err.(*url.Error); ok && uerr.Timeout() {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := annotate.Render(buf, bytes.NewReader(image), report.Detections); err != nil {
+		if errors.Is(err, annotate.ErrTooLarge) {
 			return nil, api.NewSingleMessageException(
-				api.EUNAVAILABLE,
+				api.EINVALID,
 				op,
-				"Timed out when trying to predict image. Please Try Again",
+				"Report image is too large to annotate",
 				err,
 			)
 		}
 		return nil, api.NewExceptionWithSourceLocation(
 			op,
-			"client.Do",
+			"annotate.Render",
 			err,
 		)
 	}
-	defer res.Body.Close()
+	s.AnnotationCache.Add(key, buf.Bytes())
 
-	if res.StatusCode != http.StatusOK {
-		return nil, &api.Exception{
-			Op:  op,
-			Err: errors.New("prediction service not returning 200 OK"),
+	return &model.AnnotatedImage{Data: buf.Bytes(), ETag: key}, nil
+}
+
+// Relabel replaces the classes and optionally the detections of a report with
+// the ones given by an admin, keeping the model's prediction alongside. Classes
+// of the given detections are always part of the report's classes.
+func (s *ReportServiceImpl) Relabel(
+	ctx context.Context,
+	userID,
+	reportID int,
+	relabelReportDTO *model.RelabelReportDTO) (*entity.Report, error) {
+	classes := map[string]bool{}
+	for _, class := range relabelReportDTO.Classes {
+		classes[class] = true
+	}
+	detections := make([]*entity.ReportDetection, 0, len(relabelReportDTO.Detections))
+	for _, detection := range relabelReportDTO.Detections {
+		classes[detection.Class] = true
+		detections = append(detections, &entity.ReportDetection{
+			Class:  detection.Class,
+			Score:  1,
+			Source: entity.DetectionSourceHuman,
+			Box: &entity.Box{
+				XMin: detection.Box.XMin,
+				YMin: detection.Box.YMin,
+				XMax: detection.Box.XMax,
+				YMax: detection.Box.YMax,
+			},
+		})
+	}
+	labels := []string{}
+	for _, class := range entity.ReportClasses {
+		if classes[class] {
+			labels = append(labels, class)
 		}
 	}
+	detectionsRelabeled := relabelReportDTO.Detections != nil
 
-	resBody, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		return nil, api.NewExceptionWithSourceLocation(
-			op,
-			"ioutil.ReadAll",
-			err,
-		)
+	if err := driver.WithTransaction(s.App.DB, func(e driver.Executor) error {
+		if _, err := s.ReportRepository.GetStatusForUpdate(ctx, e, reportID); err != nil {
+			return err
+		}
+
+		if detectionsRelabeled {
+			if err := s.ReportDetectionRepository.DeleteBySource(ctx, e, reportID, entity.DetectionSourceHuman); err != nil {
+				return err
+			}
+			if _, err := s.ReportDetectionRepository.CreateAll(ctx, e, reportID, detections); err != nil {
+				return err
+			}
+		}
+
+		return s.ReportRepository.Relabel(ctx, e, reportID, labels, detectionsRelabeled, userID)
+	}); err != nil {
+		return nil, err
 	}
-	predictResult := struct {
-		Data *model.PredictResult `json:"data"`
-	}{}
-	if err := json.Unmarshal(resBody, &predictResult); err != nil {
-		return nil, api.NewExceptionWithSourceLocation(
-			op,
-			"json.Unmarshal",
-			err,
-		)
+
+	return s.get(ctx, reportID)
+}
+
+// datasetBatchSize is how many reports ExportDataset reads at a time, so that
+// no database connection is held while their photos are downloaded.
+const datasetBatchSize = 50
+
+// ExportDataset calls fn with every report whose detections were relabeled by
+// an admin, along with its photo, to build a training dataset. Reports whose
+// photo cannot be downloaded are left out and counted in skipped.
+func (s *ReportServiceImpl) ExportDataset(
+	ctx context.Context,
+	filter *model.ReportFilter,
+	fn func(*entity.Report, []byte) error) (skipped int, err error) {
+	const op = "ReportServiceImpl.ExportDataset"
+	datasetFilter := model.ReportFilter{}
+	if filter != nil {
+		datasetFilter = *filter
 	}
-	report.ImageURL = predictResult.Data.ImageUrl
-	report.Classes = predictResult.Data.Classes
+	datasetFilter.Relabeled = true
+	// Duplicates are still separate photos worth training on.
+	datasetFilter.IncludeDuplicates = true
 
-	user := new(entity.User)
-	driver.WithTransaction(s.App.DB, func(e driver.Executor) error {
-		user, err = s.UserRepository.Get(ctx, s.App.DB, report.UserID)
+	afterID := 0
+	for {
+		reportIDs, err := s.ReportRepository.GetIDs(ctx, s.App.DB, &datasetFilter, afterID, datasetBatchSize)
 		if err != nil {
-			return err
+			return skipped, err
 		}
-		report, err = s.ReportRepository.Create(ctx, s.App.DB, user.ID, report)
-		if err != nil {
-			return err
+		if len(reportIDs) == 0 {
+			return skipped, nil
 		}
-		report.ReporterName = user.Name
+		afterID = reportIDs[len(reportIDs)-1]
 
-		return nil
-	})
+		batchFilter := datasetFilter
+		batchFilter.IDs = reportIDs
+		reports := make([]*entity.Report, 0, len(reportIDs))
+		if err := s.ReportRepository.Stream(ctx, s.App.DB, &batchFilter, func(report *entity.Report) error {
+			reports = append(reports, report)
+			return nil
+		}); err != nil {
+			return skipped, err
+		}
 
-	return report, nil
-}
+		for _, report := range reports {
+			if err := s.loadDetections(ctx, s.App.DB, report); err != nil {
+				return skipped, err
+			}
+			image, err := s.ImageFetcher.Fetch(ctx, report.ImageURL)
+			if err != nil {
+				if ctx.Err() != nil {
+					return skipped, ctx.Err()
+				}
+				logger.Error(op, &model.SourceLocation{Function: "s.ImageFetcher.Fetch"}, fmt.Errorf("report %d: %w", report.ID, err))
+				skipped++
+				continue
+			}
 
-func (s *ReportServiceImpl) GetAll(ctx context.Context, pagination *model.Pagination) ([]*entity.Report, error) {
-	return s.ReportRepository.GetAll(ctx, s.App.DB, pagination)
+			if err := fn(report, image); err != nil {
+				return skipped, err
+			}
+		}
+	}
 }
 
-func (s *ReportServiceImpl) GetAllByUserID(ctx context.Context, userID int, pagination *model.Pagination) ([]*entity.Report, error) {
-	return s.ReportRepository.GetAllByUserID(ctx, s.App.DB, userID, pagination)
+// annotationKey identifies a rendering of the photo and detections of report.
+func annotationKey(report *entity.Report) (string, error) {
+	b, err := json.Marshal(report.Detections)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	io.WriteString(h, report.ImageURL)
+	h.Write(b)
+
+	return fmt.Sprintf("%d-%x", report.ID, h.Sum(nil)[:12]), nil
 }
 
-func (s *ReportServiceImpl) Update(ctx context.Context, status string, reportID int) (*entity.Report, error) {
-	return s.ReportRepository.Update(ctx, s.App.DB, status, reportID)
+const (
+	individualReportZoom = 16
+	maxIndividualReports = 500
+	clusterCellPixels    = 64
+)
+
+// clusterCellSize returns the width in degrees of a clusterCellPixels wide
+// cell on a 256 pixel web map tile at the given zoom level.
+func clusterCellSize(zoom int) float64 {
+	return 360 * clusterCellPixels / (256 * math.Pow(2, float64(zoom)))
 }
 
 func allowedFileFormats(format string) bool {
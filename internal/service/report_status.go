@@ -0,0 +1,59 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
+)
+
+const (
+	StatusNeedsReview = "Needs Review"
+	StatusReported    = "Reported"
+	StatusUnderRepair = "Under Repair"
+	StatusCompleted   = "Completed"
+	StatusRejected    = "Rejected"
+)
+
+// reportStatusTransitions lists, for every status, the statuses a report is
+// allowed to move to next. Completed and Rejected are terminal.
+var reportStatusTransitions = map[string][]string{
+	StatusNeedsReview: {StatusReported, StatusRejected},
+	StatusReported:    {StatusUnderRepair, StatusRejected},
+	StatusUnderRepair: {StatusCompleted, StatusRejected},
+	StatusCompleted:   {},
+	StatusRejected:    {},
+}
+
+func canTransitionStatus(from, to string) bool {
+	for _, next := range reportStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validateStatusTransition(op, from, to string) error {
+	if canTransitionStatus(from, to) {
+		return nil
+	}
+
+	var message string
+	switch {
+	case from == to:
+		message = fmt.Sprintf("Report is already %s", from)
+	case len(reportStatusTransitions[from]) == 0:
+		message = fmt.Sprintf("Report is already %s and its status can no longer be changed", from)
+	default:
+		message = fmt.Sprintf("Cannot change report status from %s to %s", from, to)
+	}
+
+	return api.NewSingleMessageException(
+		api.ECONFLICT,
+		op,
+		message,
+		errors.New("illegal report status transition"),
+	)
+}
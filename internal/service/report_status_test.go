@@ -0,0 +1,49 @@
+package service
+
+import (
+	"testing"
+
+	"gitlab.com/harta-tahta-coursera/rodavis-api/internal/api"
+)
+
+func TestValidateStatusTransition(t *testing.T) {
+	cases := []struct {
+		from    string
+		to      string
+		allowed bool
+	}{
+		{StatusNeedsReview, StatusReported, true},
+		{StatusNeedsReview, StatusRejected, true},
+		{StatusNeedsReview, StatusUnderRepair, false},
+		{StatusReported, StatusNeedsReview, false},
+		{StatusReported, StatusUnderRepair, true},
+		{StatusReported, StatusRejected, true},
+		{StatusReported, StatusCompleted, false},
+		{StatusReported, StatusReported, false},
+		{StatusUnderRepair, StatusCompleted, true},
+		{StatusUnderRepair, StatusRejected, true},
+		{StatusUnderRepair, StatusReported, false},
+		{StatusCompleted, StatusReported, false},
+		{StatusCompleted, StatusUnderRepair, false},
+		{StatusRejected, StatusReported, false},
+		{StatusRejected, StatusUnderRepair, false},
+	}
+
+	for _, c := range cases {
+		err := validateStatusTransition("test", c.from, c.to)
+		if c.allowed && err != nil {
+			t.Errorf("Expecting transition from %q to %q to be allowed, but got %v instead", c.from, c.to, err)
+		}
+
+		if !c.allowed {
+			if err == nil {
+				t.Errorf("Expecting transition from %q to %q to be rejected", c.from, c.to)
+				continue
+			}
+
+			if code := api.ExceptionCode(err); code != api.ECONFLICT {
+				t.Errorf("Expecting error code to be %q, but got %q instead", api.ECONFLICT, code)
+			}
+		}
+	}
+}
@@ -52,7 +52,7 @@ func (s *UserServiceImpl) Create(ctx context.Context, createUserDTO *model.Creat
 		return nil, err
 	}
 
-	token, err := utils.CreateToken(&model.UserPayload{newUser.ID, newUser.Email, newUser.Role})
+	token, err := utils.CreateToken(&model.UserPayload{ID: newUser.ID, Email: newUser.Email, Role: newUser.Role})
 	if err != nil {
 		return nil, api.NewExceptionWithSourceLocation(
 			op,
@@ -94,7 +94,7 @@ func (s *UserServiceImpl) Auth(ctx context.Context, loginDTO *model.LoginDTO) (*
 		)
 	}
 
-	token, err := utils.CreateToken(&model.UserPayload{user.ID, user.Email, user.Role})
+	token, err := utils.CreateToken(&model.UserPayload{ID: user.ID, Email: user.Email, Role: user.Role})
 	if err != nil {
 		return nil, api.NewExceptionWithSourceLocation(
 			op,